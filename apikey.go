@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/animans/REST-API-test-task/auth"
+	"github.com/animans/REST-API-test-task/config"
+	"github.com/animans/REST-API-test-task/domain"
+)
+
+// apiKeyUsage ...
+const apiKeyUsage = "usage: apikey create -name name [-user id] [-scopes admin|read,write] [-expires 720h]"
+
+// runAPIKey issues API keys directly in the database, so the first admin key
+// does not need an existing one.
+func runAPIKey(ctx context.Context, cfg config.Config, args []string) error {
+	if cfg.Storage.Kind != config.StoragePostgres {
+		return errors.New("apikey needs postgres storage, keys in memory storage are gone when the command exits")
+	}
+	if len(args) == 0 || args[0] != "create" {
+		return errors.New(apiKeyUsage)
+	}
+	fs := flag.NewFlagSet("apikey create", flag.ContinueOnError)
+	name := fs.String("name", "", "key name")
+	user := fs.String("user", "", "user_id the key acts for, required unless scopes include admin")
+	scopes := fs.String("scopes", domain.ScopeAdmin, "comma separated read, write, admin")
+	expires := fs.Duration("expires", 0, "lifetime, 0 never expires")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return errors.New(apiKeyUsage)
+	}
+
+	now := time.Now()
+	in := domain.CreateAPIKeyRequest{Name: *name, UserID: *user, Scopes: strings.Split(*scopes, ",")}
+	if *expires > 0 {
+		at := now.Add(*expires)
+		in.ExpiresAt = &at
+	}
+	k, err := in.ToAPIKey(now)
+	if err != nil {
+		return err
+	}
+	secret, prefix, hash, err := auth.GenerateAPIKey()
+	if err != nil {
+		return err
+	}
+	k.Prefix, k.Hash = prefix, hash
+
+	repo, err := openStorage(cfg.Storage)
+	if err != nil {
+		return err
+	}
+	defer repo.Close()
+	if err := newAPIKeyStorage(repo, cfg.Storage).Create(ctx, k); err != nil {
+		return fmt.Errorf("create api key: %w", err)
+	}
+	fmt.Printf("id:     %s\nscopes: %s\nkey:    %s\n", k.ID, strings.Join(k.Scopes, ","), secret)
+	return nil
+}
@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/animans/REST-API-test-task/domain"
+	"github.com/animans/REST-API-test-task/logging"
+)
+
+// APIKeyHeader carries the API key secret.
+const APIKeyHeader = "X-API-Key"
+
+// DefaultTouchInterval limits last_used_at writes to one per key and interval.
+const DefaultTouchInterval = time.Minute
+
+// GenerateAPIKey returns a new secret, its display prefix and the hash to store.
+func GenerateAPIKey() (secret, prefix, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", "", fmt.Errorf("generate api key: %w", err)
+	}
+	secret = "sk_" + base64.RawURLEncoding.EncodeToString(b)
+	return secret, secret[:8], HashAPIKey(secret), nil
+}
+
+// HashAPIKey ...
+func HashAPIKey(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// APIKeyAuthenticator accepts keys sent in the X-API-Key header.
+type APIKeyAuthenticator struct {
+	repo domain.APIKeyRepository
+	now  func() time.Time
+	// TouchInterval is the minimum time between two last_used_at updates of a key.
+	TouchInterval time.Duration
+}
+
+// NewAPIKeyAuthenticator ...
+func NewAPIKeyAuthenticator(repo domain.APIKeyRepository) *APIKeyAuthenticator {
+	return &APIKeyAuthenticator{repo: repo, now: time.Now, TouchInterval: DefaultTouchInterval}
+}
+
+// Authenticate ...
+func (a *APIKeyAuthenticator) Authenticate(r *http.Request) (Principal, error) {
+	secret := r.Header.Get(APIKeyHeader)
+	if secret == "" {
+		return Principal{}, ErrNoCredentials
+	}
+	ctx := r.Context()
+	k, err := a.repo.GetByHash(ctx, HashAPIKey(secret))
+	if errors.Is(err, domain.ErrAPIKeyNotFound) {
+		return Principal{}, fmt.Errorf("%w: unknown api key", ErrInvalidCredentials)
+	}
+	if err != nil {
+		return Principal{}, err
+	}
+	now := a.now()
+	if !k.Active(now) {
+		return Principal{}, fmt.Errorf("%w: api key %s revoked or expired", ErrInvalidCredentials, k.ID)
+	}
+	if k.LastUsedAt == nil || now.Sub(*k.LastUsedAt) >= a.TouchInterval {
+		if err := a.repo.Touch(ctx, k.ID, now); err != nil {
+			logging.FromContext(ctx).Warn("api key touch failed", "key_id", k.ID, "err", err)
+		}
+	}
+
+	// Only the admin scope lifts the per-user restriction; a key without a user has nothing else to act as.
+	admin := k.HasScope(domain.ScopeAdmin)
+	if k.UserID == nil && !admin {
+		return Principal{}, fmt.Errorf("%w: api key %s has no user", ErrInvalidCredentials, k.ID)
+	}
+	p := Principal{Method: "api_key", KeyID: k.ID, Scopes: k.Scopes, Admin: admin}
+	if k.UserID != nil {
+		p.UserID = *k.UserID
+	}
+	return p, nil
+}
@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/animans/REST-API-test-task/domain"
+	"github.com/animans/REST-API-test-task/infastructure"
+	"github.com/google/uuid"
+)
+
+func issue(t *testing.T, repo domain.APIKeyRepository, k *domain.APIKey) string {
+	t.Helper()
+	secret, prefix, hash, err := GenerateAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(secret, prefix) || hash != HashAPIKey(secret) || strings.Contains(hash, secret) {
+		t.Fatalf("GenerateAPIKey: %q %q %q", secret, prefix, hash)
+	}
+	k.Prefix, k.Hash = prefix, hash
+	if err := repo.Create(t.Context(), k); err != nil {
+		t.Fatal(err)
+	}
+	return secret
+}
+
+func TestAPIKeyAuthenticator(t *testing.T) {
+	repo := infastructure.NewAPIKeyRepoMem()
+	a := NewAPIKeyAuthenticator(repo)
+	now := time.Date(2025, time.October, 1, 12, 0, 0, 0, time.UTC)
+	a.now = func() time.Time { return now }
+
+	user := uuid.New()
+	past := now.Add(-time.Hour)
+	userKey := issue(t, repo, &domain.APIKey{Name: "user", UserID: &user, Scopes: []string{domain.ScopeRead}})
+	serviceKey := issue(t, repo, &domain.APIKey{Name: "sync", Scopes: []string{domain.ScopeRead, domain.ScopeWrite}})
+	adminKey := issue(t, repo, &domain.APIKey{Name: "ops", Scopes: []string{domain.ScopeRead, domain.ScopeAdmin}})
+	expired := issue(t, repo, &domain.APIKey{Name: "old", Scopes: []string{domain.ScopeRead}, ExpiresAt: &past})
+	revoked := &domain.APIKey{Name: "revoked", Scopes: []string{domain.ScopeAdmin}}
+	revokedKey := issue(t, repo, revoked)
+	if err := repo.Revoke(t.Context(), revoked.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	call := func(secret string) (Principal, error) {
+		r := httptest.NewRequest("GET", "/service", nil)
+		if secret != "" {
+			r.Header.Set(APIKeyHeader, secret)
+		}
+		return a.Authenticate(r)
+	}
+
+	p, err := call(userKey)
+	if err != nil || p.UserID != user || p.Admin || !p.HasScope(domain.ScopeRead) || p.HasScope(domain.ScopeWrite) {
+		t.Fatalf("user key: got %+v, %v", p, err)
+	}
+	if p, err := call(adminKey); err != nil || !p.Admin || p.UserID != uuid.Nil {
+		t.Fatalf("admin key: got %+v, %v", p, err)
+	}
+	if _, err := call(""); !errors.Is(err, ErrNoCredentials) {
+		t.Fatalf("no key: got %v", err)
+	}
+	for name, secret := range map[string]string{"unknown": "sk_nope", "expired": expired, "revoked": revokedKey, "unbound": serviceKey} {
+		if _, err := call(secret); !errors.Is(err, ErrInvalidCredentials) {
+			t.Errorf("%s: got %v", name, err)
+		}
+	}
+
+	lastUsed := func() time.Time {
+		k, err := repo.GetByHash(t.Context(), HashAPIKey(userKey))
+		if err != nil || k.LastUsedAt == nil {
+			t.Fatalf("last used: %+v, %v", k, err)
+		}
+		return *k.LastUsedAt
+	}
+	if got := lastUsed(); !got.Equal(now) {
+		t.Fatalf("last used: got %v want %v", got, now)
+	}
+	first := now
+	now = now.Add(a.TouchInterval / 2)
+	_, _ = call(userKey)
+	if got := lastUsed(); !got.Equal(first) {
+		t.Fatalf("touched within interval: got %v", got)
+	}
+	now = now.Add(a.TouchInterval)
+	_, _ = call(userKey)
+	if got := lastUsed(); !got.Equal(now) {
+		t.Fatalf("not touched after interval: got %v", got)
+	}
+}
+
+// stubAuth returns a fixed result.
+type stubAuth struct {
+	p   Principal
+	err error
+}
+
+func (s stubAuth) Authenticate(*http.Request) (Principal, error) { return s.p, s.err }
+
+func TestChain(t *testing.T) {
+	r := httptest.NewRequest("GET", "/service", nil)
+	none := stubAuth{err: ErrNoCredentials}
+	bad := stubAuth{err: ErrInvalidCredentials}
+	good := stubAuth{p: Principal{Method: "good"}}
+
+	if p, err := (Chain{none, good}).Authenticate(r); err != nil || p.Method != "good" {
+		t.Fatalf("fallthrough: got %+v, %v", p, err)
+	}
+	if _, err := (Chain{bad, good}).Authenticate(r); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("invalid credentials must not fall through: got %v", err)
+	}
+	if _, err := (Chain{none, none}).Authenticate(r); !errors.Is(err, ErrNoCredentials) {
+		t.Fatalf("empty: got %v", err)
+	}
+}
@@ -0,0 +1,65 @@
+// Package auth authenticates API callers and carries the caller identity in a context.Context.
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+
+	"github.com/google/uuid"
+)
+
+// Authentication errors.
+var (
+	ErrNoCredentials      = errors.New("missing credentials")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Principal is the authenticated caller.
+type Principal struct {
+	// UserID owns the subscriptions the caller may access unless Admin is set.
+	UserID uuid.UUID
+	// Admin lifts the per-user restriction, e.g. for admins and API keys with the admin scope.
+	Admin bool
+	// Scopes are the domain.Scope* values the caller was granted.
+	Scopes []string
+	// Method names the authenticator that accepted the request, e.g. "jwt".
+	Method string
+	// KeyID is the API key used, uuid.Nil for other methods.
+	KeyID uuid.UUID
+}
+
+// HasScope ...
+func (p Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, scope)
+}
+
+// Authenticator extracts a Principal from a request.
+// It returns ErrNoCredentials when the request carries none of its credentials.
+type Authenticator interface {
+	Authenticate(r *http.Request) (Principal, error)
+}
+
+type ctxKey struct{}
+
+// WithPrincipal ...
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, ctxKey{}, p)
+}
+
+// FromContext ...
+func FromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(ctxKey{}).(Principal)
+	return p, ok
+}
+
+// Scope returns the user the caller is restricted to, or nil for admins
+// and when the request was not authenticated at all (auth disabled).
+func Scope(ctx context.Context) *uuid.UUID {
+	p, ok := FromContext(ctx)
+	if !ok || p.Admin {
+		return nil
+	}
+	return &p.UserID
+}
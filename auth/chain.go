@@ -0,0 +1,22 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Chain tries each authenticator in order. The first one that finds its
+// credentials on the request decides; the rest are never consulted.
+type Chain []Authenticator
+
+// Authenticate ...
+func (c Chain) Authenticate(r *http.Request) (Principal, error) {
+	for _, a := range c {
+		p, err := a.Authenticate(r)
+		if errors.Is(err, ErrNoCredentials) {
+			continue
+		}
+		return p, err
+	}
+	return Principal{}, ErrNoCredentials
+}
@@ -0,0 +1,198 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/animans/REST-API-test-task/config"
+	"github.com/animans/REST-API-test-task/domain"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// JWTAuthenticator validates HS256/RS256 bearer tokens.
+type JWTAuthenticator struct {
+	parser     *jwt.Parser
+	secret     []byte
+	publicKey  *rsa.PublicKey
+	keys       map[string]any
+	userClaim  string
+	rolesClaim string
+	adminRole  string
+}
+
+// NewJWTAuthenticator loads the keys configured in c.
+func NewJWTAuthenticator(c config.Auth) (*JWTAuthenticator, error) {
+	a := &JWTAuthenticator{
+		keys:       map[string]any{},
+		userClaim:  c.UserClaim,
+		rolesClaim: c.RolesClaim,
+		adminRole:  c.AdminRole,
+	}
+	if c.JWTSecret != "" {
+		a.secret = []byte(c.JWTSecret)
+	}
+	if c.JWTPublicKeyFile != "" {
+		b, err := os.ReadFile(c.JWTPublicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("read public key: %w", err)
+		}
+		if a.publicKey, err = jwt.ParseRSAPublicKeyFromPEM(b); err != nil {
+			return nil, fmt.Errorf("parse public key: %w", err)
+		}
+	}
+	if c.JWKSFile != "" {
+		if err := a.loadJWKS(c.JWKSFile); err != nil {
+			return nil, err
+		}
+	}
+	if a.secret == nil && a.publicKey == nil && len(a.keys) == 0 {
+		return nil, errors.New("no JWT verification key configured")
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"HS256", "RS256"}),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(30 * time.Second),
+	}
+	if c.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(c.Issuer))
+	}
+	if c.Audience != "" {
+		opts = append(opts, jwt.WithAudience(c.Audience))
+	}
+	a.parser = jwt.NewParser(opts...)
+	return a, nil
+}
+
+// jwk is the subset of RFC 7517 fields used for RSA and symmetric keys.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	K   string `json:"k"`
+}
+
+// loadJWKS reads a local JWKS file; a single key without kid becomes the default key.
+func (a *JWTAuthenticator) loadJWKS(file string) error {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("read jwks: %w", err)
+	}
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(b, &set); err != nil {
+		return fmt.Errorf("parse jwks: %w", err)
+	}
+	enc := base64.RawURLEncoding
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		var key any
+		switch k.Kty {
+		case "RSA":
+			n, err1 := enc.DecodeString(k.N)
+			e, err2 := enc.DecodeString(k.E)
+			if err := errors.Join(err1, err2); err != nil {
+				return fmt.Errorf("jwks key %q: %w", k.Kid, err)
+			}
+			key = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		case "oct":
+			secret, err := enc.DecodeString(k.K)
+			if err != nil {
+				return fmt.Errorf("jwks key %q: %w", k.Kid, err)
+			}
+			key = secret
+		default:
+			continue
+		}
+		if k.Kid == "" {
+			switch v := key.(type) {
+			case *rsa.PublicKey:
+				a.publicKey = v
+			case []byte:
+				a.secret = v
+			}
+			continue
+		}
+		a.keys[k.Kid] = key
+	}
+	return nil
+}
+
+// keyFunc picks the verification key by kid, then by algorithm.
+func (a *JWTAuthenticator) keyFunc(t *jwt.Token) (any, error) {
+	kid, _ := t.Header["kid"].(string)
+	switch t.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		if k, ok := a.keys[kid].([]byte); ok {
+			return k, nil
+		}
+		if kid == "" && a.secret != nil {
+			return a.secret, nil
+		}
+	case *jwt.SigningMethodRSA:
+		if k, ok := a.keys[kid].(*rsa.PublicKey); ok {
+			return k, nil
+		}
+		if kid == "" && a.publicKey != nil {
+			return a.publicKey, nil
+		}
+	}
+	return nil, fmt.Errorf("no key for alg %s kid %q", t.Method.Alg(), kid)
+}
+
+// Authenticate ...
+func (a *JWTAuthenticator) Authenticate(r *http.Request) (Principal, error) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return Principal{}, ErrNoCredentials
+	}
+	claims := jwt.MapClaims{}
+	if _, err := a.parser.ParseWithClaims(strings.TrimSpace(token), claims, a.keyFunc); err != nil {
+		return Principal{}, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+
+	p := Principal{Method: "jwt", Admin: slices.Contains(roles(claims[a.rolesClaim]), a.adminRole)}
+	p.Scopes = []string{domain.ScopeRead, domain.ScopeWrite}
+	if p.Admin {
+		p.Scopes = append(p.Scopes, domain.ScopeAdmin)
+	}
+	sub, _ := claims[a.userClaim].(string)
+	id, err := uuid.Parse(sub)
+	if err != nil && !p.Admin {
+		return Principal{}, fmt.Errorf("%w: claim %s is not a user uuid", ErrInvalidCredentials, a.userClaim)
+	}
+	p.UserID = id
+	return p, nil
+}
+
+// roles accepts a JSON array or a space separated string claim.
+func roles(v any) []string {
+	switch v := v.(type) {
+	case string:
+		return strings.Fields(v)
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, r := range v {
+			if s, ok := r.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/animans/REST-API-test-task/config"
+	"github.com/animans/REST-API-test-task/domain"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+func sign(t *testing.T, method jwt.SigningMethod, key any, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	tok := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		tok.Header["kid"] = kid
+	}
+	s, err := tok.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func authenticate(a Authenticator, token string) (Principal, error) {
+	r := httptest.NewRequest("GET", "/service", nil)
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	return a.Authenticate(r)
+}
+
+func authConfig() config.Auth {
+	c := config.Default().Auth
+	c.Issuer = "test"
+	return c
+}
+
+func TestJWTHS256(t *testing.T) {
+	c := authConfig()
+	c.JWTSecret = "secret"
+	a, err := NewJWTAuthenticator(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := uuid.New()
+	exp := time.Now().Add(time.Hour).Unix()
+	key := []byte("secret")
+
+	p, err := authenticate(a, sign(t, jwt.SigningMethodHS256, key, "", jwt.MapClaims{"sub": user.String(), "iss": "test", "exp": exp}))
+	if err != nil || p.UserID != user || p.Admin || !p.HasScope(domain.ScopeWrite) || p.HasScope(domain.ScopeAdmin) {
+		t.Fatalf("user token: got %+v, %v", p, err)
+	}
+	p, err = authenticate(a, sign(t, jwt.SigningMethodHS256, key, "", jwt.MapClaims{"sub": "ops", "iss": "test", "exp": exp, "roles": []string{"admin"}}))
+	if err != nil || !p.Admin || !p.HasScope(domain.ScopeAdmin) {
+		t.Fatalf("admin token: got %+v, %v", p, err)
+	}
+
+	if _, err := authenticate(a, ""); !errors.Is(err, ErrNoCredentials) {
+		t.Fatalf("no token: got %v", err)
+	}
+	for name, tok := range map[string]string{
+		"expired":    sign(t, jwt.SigningMethodHS256, key, "", jwt.MapClaims{"sub": user.String(), "iss": "test", "exp": time.Now().Add(-time.Hour).Unix()}),
+		"no exp":     sign(t, jwt.SigningMethodHS256, key, "", jwt.MapClaims{"sub": user.String(), "iss": "test"}),
+		"wrong key":  sign(t, jwt.SigningMethodHS256, []byte("other"), "", jwt.MapClaims{"sub": user.String(), "iss": "test", "exp": exp}),
+		"wrong iss":  sign(t, jwt.SigningMethodHS256, key, "", jwt.MapClaims{"sub": user.String(), "iss": "evil", "exp": exp}),
+		"not a uuid": sign(t, jwt.SigningMethodHS256, key, "", jwt.MapClaims{"sub": "bob", "iss": "test", "exp": exp}),
+		"alg none":   sign(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, "", jwt.MapClaims{"sub": user.String(), "iss": "test", "exp": exp}),
+		"garbage":    "a.b.c",
+	} {
+		if _, err := authenticate(a, tok); !errors.Is(err, ErrInvalidCredentials) {
+			t.Errorf("%s: got %v", name, err)
+		}
+	}
+}
+
+func TestJWTRS256JWKS(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc := base64.RawURLEncoding
+	jwks, _ := json.Marshal(map[string]any{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "k1", "use": "sig", "n": enc.EncodeToString(priv.N.Bytes()), "e": enc.EncodeToString(big.NewInt(int64(priv.E)).Bytes())},
+		{"kty": "oct", "kid": "h1", "k": enc.EncodeToString([]byte("hmac-key"))},
+	}})
+	file := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(file, jwks, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	c := authConfig()
+	c.JWKSFile = file
+	a, err := NewJWTAuthenticator(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := uuid.New()
+	claims := jwt.MapClaims{"sub": user.String(), "iss": "test", "exp": time.Now().Add(time.Hour).Unix()}
+	if p, err := authenticate(a, sign(t, jwt.SigningMethodRS256, priv, "k1", claims)); err != nil || p.UserID != user {
+		t.Fatalf("RS256: got %+v, %v", p, err)
+	}
+	if _, err := authenticate(a, sign(t, jwt.SigningMethodHS256, []byte("hmac-key"), "h1", claims)); err != nil {
+		t.Fatalf("HS256 by kid: %v", err)
+	}
+	if _, err := authenticate(a, sign(t, jwt.SigningMethodRS256, priv, "k2", claims)); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("unknown kid: got %v", err)
+	}
+	// An RS256 key must never be usable as an HMAC secret.
+	if _, err := authenticate(a, sign(t, jwt.SigningMethodHS256, []byte("hmac-key"), "k1", claims)); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("alg confusion: got %v", err)
+	}
+}
+
+func TestNewJWTAuthenticatorNoKeys(t *testing.T) {
+	if _, err := NewJWTAuthenticator(authConfig()); err == nil {
+		t.Fatal("expected error without keys")
+	}
+}
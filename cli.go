@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/animans/REST-API-test-task/config"
+	"github.com/animans/REST-API-test-task/domain"
+	"github.com/google/uuid"
+)
+
+// command is a subcommand of the binary; args are the words after its name.
+type command struct {
+	summary string
+	run     func(ctx context.Context, cfg config.Config, args []string) error
+}
+
+// commands ...
+var commands = map[string]command{
+	"serve": {"serve the HTTP API (default)", func(ctx context.Context, cfg config.Config, args []string) error {
+		if len(args) > 0 {
+			return fmt.Errorf("serve takes no arguments, got %q", args)
+		}
+		return run(ctx, cfg)
+	}},
+	"migrate": {"apply the embedded migrations: up | down [steps] | goto <version> | force <version> | status", runMigrate},
+	"seed":    {"insert random subscriptions; with STORAGE=memory they are gone when the command exits", runSeed},
+	"import":  {"import subscriptions from JSON lines or CSV; with STORAGE=memory they are gone when the command exits", runImport},
+	"export":  {"export subscriptions as JSON lines or CSV", runExport},
+	"summary": {"print the total cost of subscriptions", runSummary},
+	"apikey":  {"issue an API key, e.g. the first admin key: create -name name [-scopes admin]", runAPIKey},
+}
+
+// usage ...
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "usage: %s [flags] [command] [args]\n\ncommands:\n", os.Args[0])
+	for _, name := range slices.Sorted(maps.Keys(commands)) {
+		fmt.Fprintf(out, "  %-8s %s\n", name, commands[name].summary)
+	}
+	fmt.Fprintf(out, "\nrun %s <command> -h for the flags of a command\n\nflags:\n", os.Args[0])
+	flag.PrintDefaults()
+}
+
+// warnVolatile tells that cmd saves nothing lasting on memory storage.
+func warnVolatile(c config.Storage, cmd string) {
+	if c.Kind == config.StorageMemory {
+		slog.Warn(cmd+" on memory storage, the data is gone when the command exits", "storage", c.Kind)
+	}
+}
+
+// openStorage opens the configured backend, the caller closes it.
+func openStorage(c config.Storage) (storage, error) {
+	repo := newStorage(c)
+	if err := repo.Open(); err != nil {
+		return nil, fmt.Errorf("repo open: %w", err)
+	}
+	return repo, nil
+}
+
+// filterFlags are the subscription filters shared by export and summary.
+type filterFlags struct {
+	user, name, from, to string
+}
+
+// register ...
+func (f *filterFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.user, "user", "", "only this user_id")
+	fs.StringVar(&f.name, "name", "", "only this service_name")
+	fs.StringVar(&f.from, "from", "", "first month, MM-YYYY")
+	fs.StringVar(&f.to, "to", "", "last month, MM-YYYY")
+}
+
+// parse validates the flags like the HTTP query parameters of the same name.
+func (f *filterFlags) parse() (name string, user *uuid.UUID, from, to *time.Time, err error) {
+	var verr domain.ValidationError
+	if f.user != "" {
+		id, err := uuid.Parse(f.user)
+		if err != nil {
+			verr.Add("user", "invalid uuid")
+		}
+		user = &id
+	}
+	month := func(flag, v string) *time.Time {
+		if v == "" {
+			return nil
+		}
+		t, err := time.Parse(domain.MonthLayout, v)
+		if err != nil {
+			verr.Add(flag, "want MM-YYYY")
+			return nil
+		}
+		return &t
+	}
+	from, to = month("from", f.from), month("to", f.to)
+	if from != nil && to != nil && to.Before(*from) {
+		verr.Add("to", "must be >= from")
+	}
+	return strings.TrimSpace(f.name), user, from, to, verr.Err()
+}
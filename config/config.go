@@ -0,0 +1,513 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v2"
+)
+
+// Storage kinds.
+const (
+	StoragePostgres = "postgres"
+	StorageMemory   = "memory"
+)
+
+// Tracing exporters.
+const (
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// placeholderSecret is the sample secret of the shipped config files.
+const placeholderSecret = "change-me"
+
+// MinJWTSecretLen is the shortest accepted HS256 secret, in bytes.
+const MinJWTSecretLen = 32
+
+// Config is the application configuration.
+// Sources are applied in order: defaults, the config file, .env, then the process environment.
+type Config struct {
+	LogLevel  string    `yaml:"log_level"`
+	HTTP      HTTP      `yaml:"http"`
+	Storage   Storage   `yaml:"storage"`
+	Tracing   Tracing   `yaml:"tracing"`
+	Auth      Auth      `yaml:"auth"`
+	RateLimit RateLimit `yaml:"rate_limit"`
+}
+
+// HTTP ...
+type HTTP struct {
+	Addr              string        `yaml:"addr"`
+	ReadTimeout       time.Duration `yaml:"read_timeout"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout"`
+	WriteTimeout      time.Duration `yaml:"write_timeout"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout"`
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout"`
+	ReadyTimeout      time.Duration `yaml:"ready_timeout"`
+	MaxHeaderBytes    int           `yaml:"max_header_bytes"`
+	MaxImportBytes    int           `yaml:"max_import_bytes"`
+	ExportTimeout     time.Duration `yaml:"export_timeout"`
+	CursorSecret      string        `yaml:"cursor_secret"`
+}
+
+// Storage ...
+type Storage struct {
+	Kind         string        `yaml:"kind"`
+	DatabaseURL  string        `yaml:"database_url"`
+	QueryTimeout time.Duration `yaml:"query_timeout"`
+	// MigrateOnStart applies pending migrations before serving (postgres only).
+	MigrateOnStart bool `yaml:"migrate_on_start"`
+}
+
+// Tracing ...
+type Tracing struct {
+	Enabled  bool   `yaml:"enabled"`
+	Exporter string `yaml:"exporter"`
+	// Endpoint is the OTLP/HTTP collector host:port.
+	Endpoint    string  `yaml:"endpoint"`
+	Insecure    bool    `yaml:"insecure"`
+	SampleRatio float64 `yaml:"sample_ratio"`
+	ServiceName string  `yaml:"service_name"`
+}
+
+// Auth configures bearer token and API key authentication.
+type Auth struct {
+	Enabled bool `yaml:"enabled"`
+	// APIKeys accepts keys from the api_keys table in the X-API-Key header.
+	APIKeys bool `yaml:"api_keys"`
+	// JWTSecret verifies HS256 tokens.
+	JWTSecret string `yaml:"jwt_secret"`
+	// JWTPublicKeyFile is a PEM RSA public key verifying RS256 tokens.
+	JWTPublicKeyFile string `yaml:"jwt_public_key_file"`
+	// JWKSFile is a local JSON Web Key Set with RSA or symmetric keys selected by kid.
+	JWKSFile   string `yaml:"jwks_file"`
+	Issuer     string `yaml:"issuer"`
+	Audience   string `yaml:"audience"`
+	UserClaim  string `yaml:"user_claim"`
+	RolesClaim string `yaml:"roles_claim"`
+	AdminRole  string `yaml:"admin_role"`
+}
+
+// RateLimit configures the per-client token buckets on /service routes.
+type RateLimit struct {
+	Enabled bool `yaml:"enabled"`
+	// TrustProxy keys anonymous clients by the last X-Forwarded-For address, the one
+	// the proxy in front appended, instead of the peer.
+	TrustProxy bool  `yaml:"trust_proxy"`
+	Default    Limit `yaml:"default"`
+	// Auth is checked per client address before authentication, so failed
+	// credentials are throttled as well.
+	Auth Limit `yaml:"auth"`
+	// Routes overrides Default per mux route template, e.g. /service/summary.
+	Routes map[string]Limit `yaml:"routes"`
+}
+
+// Limit is a token bucket refilled at RPS tokens per second up to Burst.
+type Limit struct {
+	RPS   float64 `yaml:"rps"`
+	Burst int     `yaml:"burst"`
+}
+
+// JWT reports whether a token verification key is configured.
+func (a Auth) JWT() bool {
+	return a.JWTSecret != "" || a.JWTPublicKeyFile != "" || a.JWKSFile != ""
+}
+
+// Default ...
+func Default() Config {
+	return Config{
+		LogLevel: "info",
+		HTTP: HTTP{
+			Addr:              ":8080",
+			ReadTimeout:       15 * time.Second,
+			ReadHeaderTimeout: 5 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       60 * time.Second,
+			ShutdownTimeout:   20 * time.Second,
+			ReadyTimeout:      2 * time.Second,
+			MaxHeaderBytes:    1 << 20,
+			MaxImportBytes:    10 << 20,
+			ExportTimeout:     10 * time.Minute,
+		},
+		Storage: Storage{
+			Kind:         StoragePostgres,
+			QueryTimeout: 5 * time.Second,
+		},
+		Tracing: Tracing{
+			Exporter:    ExporterOTLP,
+			Endpoint:    "localhost:4318",
+			SampleRatio: 1,
+			ServiceName: "subscriptions-api",
+		},
+		Auth: Auth{
+			UserClaim:  "sub",
+			RolesClaim: "roles",
+			AdminRole:  "admin",
+		},
+		RateLimit: RateLimit{
+			Default: Limit{RPS: 10, Burst: 20},
+			Auth:    Limit{RPS: 20, Burst: 40},
+			Routes: map[string]Limit{
+				"/service/summary":         {RPS: 1, Burst: 5},
+				"/service/summary/monthly": {RPS: 1, Burst: 5},
+			},
+		},
+	}
+}
+
+// Load reads the configuration. An empty file falls back to CONFIG_FILE.
+func Load(file string) (Config, error) {
+	dotenv, err := godotenv.Read()
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return Config{}, fmt.Errorf("read .env: %w", err)
+	}
+	return load(file, func(key string) (string, bool) {
+		if v, ok := os.LookupEnv(key); ok {
+			return v, true
+		}
+		v, ok := dotenv[key]
+		return v, ok
+	})
+}
+
+// load ...
+func load(file string, lookup func(string) (string, bool)) (Config, error) {
+	c := Default()
+	if file == "" {
+		file, _ = lookup("CONFIG_FILE")
+	}
+	if file != "" {
+		if err := c.readFile(file); err != nil {
+			return c, err
+		}
+	}
+	if err := c.applyEnv(lookup); err != nil {
+		return c, err
+	}
+	c.Storage.Kind = normalizeStorage(c.Storage.Kind)
+	return c, c.Validate()
+}
+
+// readFile decodes a YAML or JSON file; JSON is read as YAML.
+func (c *Config) readFile(file string) error {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("read config: %w", err)
+	}
+	// A routes map in the file replaces the default one instead of merging into it.
+	routes := c.RateLimit.Routes
+	c.RateLimit.Routes = nil
+	if err := yaml.UnmarshalStrict(b, c); err != nil {
+		return fmt.Errorf("parse config %s: %w", file, err)
+	}
+	if c.RateLimit.Routes == nil {
+		c.RateLimit.Routes = routes
+	}
+	return nil
+}
+
+// applyEnv overrides c with the environment variables that are set.
+func (c *Config) applyEnv(lookup func(string) (string, bool)) error {
+	strs := map[string]*string{
+		"LOG_LEVEL":                &c.LogLevel,
+		"BIND_ADDR":                &c.HTTP.Addr,
+		"CURSOR_SECRET":            &c.HTTP.CursorSecret,
+		"STORAGE":                  &c.Storage.Kind,
+		"DATABASE_URL":             &c.Storage.DatabaseURL,
+		"TRACING_EXPORTER":         &c.Tracing.Exporter,
+		"TRACING_ENDPOINT":         &c.Tracing.Endpoint,
+		"OTEL_SERVICE_NAME":        &c.Tracing.ServiceName,
+		"AUTH_JWT_SECRET":          &c.Auth.JWTSecret,
+		"AUTH_JWT_PUBLIC_KEY_FILE": &c.Auth.JWTPublicKeyFile,
+		"AUTH_JWKS_FILE":           &c.Auth.JWKSFile,
+		"AUTH_ISSUER":              &c.Auth.Issuer,
+		"AUTH_AUDIENCE":            &c.Auth.Audience,
+		"AUTH_USER_CLAIM":          &c.Auth.UserClaim,
+		"AUTH_ROLES_CLAIM":         &c.Auth.RolesClaim,
+		"AUTH_ADMIN_ROLE":          &c.Auth.AdminRole,
+	}
+	durations := map[string]*time.Duration{
+		"HTTP_READ_TIMEOUT":        &c.HTTP.ReadTimeout,
+		"HTTP_READ_HEADER_TIMEOUT": &c.HTTP.ReadHeaderTimeout,
+		"HTTP_WRITE_TIMEOUT":       &c.HTTP.WriteTimeout,
+		"HTTP_IDLE_TIMEOUT":        &c.HTTP.IdleTimeout,
+		"HTTP_SHUTDOWN_TIMEOUT":    &c.HTTP.ShutdownTimeout,
+		"HTTP_READY_TIMEOUT":       &c.HTTP.ReadyTimeout,
+		"HTTP_EXPORT_TIMEOUT":      &c.HTTP.ExportTimeout,
+		"DB_QUERY_TIMEOUT":         &c.Storage.QueryTimeout,
+	}
+	ints := map[string]*int{
+		"HTTP_MAX_HEADER_BYTES": &c.HTTP.MaxHeaderBytes,
+		"HTTP_MAX_IMPORT_BYTES": &c.HTTP.MaxImportBytes,
+		"RATE_LIMIT_BURST":      &c.RateLimit.Default.Burst,
+		"RATE_LIMIT_AUTH_BURST": &c.RateLimit.Auth.Burst,
+	}
+	bools := map[string]*bool{
+		"TRACING_ENABLED":        &c.Tracing.Enabled,
+		"TRACING_INSECURE":       &c.Tracing.Insecure,
+		"AUTH_ENABLED":           &c.Auth.Enabled,
+		"AUTH_API_KEYS":          &c.Auth.APIKeys,
+		"MIGRATE_ON_START":       &c.Storage.MigrateOnStart,
+		"RATE_LIMIT_ENABLED":     &c.RateLimit.Enabled,
+		"RATE_LIMIT_TRUST_PROXY": &c.RateLimit.TrustProxy,
+	}
+	floats := map[string]*float64{
+		"TRACING_SAMPLE_RATIO": &c.Tracing.SampleRatio,
+		"RATE_LIMIT_RPS":       &c.RateLimit.Default.RPS,
+		"RATE_LIMIT_AUTH_RPS":  &c.RateLimit.Auth.RPS,
+	}
+
+	var errs []error
+	for key, dst := range strs {
+		if v, ok := lookup(key); ok && v != "" {
+			*dst = v
+		}
+	}
+	for key, dst := range durations {
+		v, ok := lookup(key)
+		if !ok || v == "" {
+			continue
+		}
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+			continue
+		}
+		*dst = d
+	}
+	for key, dst := range ints {
+		v, ok := lookup(key)
+		if !ok || v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+			continue
+		}
+		*dst = n
+	}
+	for key, dst := range bools {
+		v, ok := lookup(key)
+		if !ok || v == "" {
+			continue
+		}
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+			continue
+		}
+		*dst = b
+	}
+	for key, dst := range floats {
+		v, ok := lookup(key)
+		if !ok || v == "" {
+			continue
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+			continue
+		}
+		*dst = f
+	}
+	return errors.Join(errs...)
+}
+
+// normalizeStorage ...
+func normalizeStorage(kind string) string {
+	switch strings.ToLower(kind) {
+	case "memory", "mem":
+		return StorageMemory
+	case "postgres", "postgresql", "pg":
+		return StoragePostgres
+	}
+	return kind
+}
+
+// Validate reports every invalid setting at once.
+func (c Config) Validate() error {
+	var errs []error
+	if _, err := ParseLevel(c.LogLevel); err != nil {
+		errs = append(errs, err)
+	}
+	if _, _, err := net.SplitHostPort(c.HTTP.Addr); err != nil {
+		errs = append(errs, fmt.Errorf("http.addr %q: %w", c.HTTP.Addr, err))
+	}
+	for name, d := range map[string]time.Duration{
+		"http.read_timeout":        c.HTTP.ReadTimeout,
+		"http.read_header_timeout": c.HTTP.ReadHeaderTimeout,
+		"http.write_timeout":       c.HTTP.WriteTimeout,
+		"http.idle_timeout":        c.HTTP.IdleTimeout,
+		"http.shutdown_timeout":    c.HTTP.ShutdownTimeout,
+		"http.ready_timeout":       c.HTTP.ReadyTimeout,
+		"http.export_timeout":      c.HTTP.ExportTimeout,
+		"storage.query_timeout":    c.Storage.QueryTimeout,
+	} {
+		if d < 0 {
+			errs = append(errs, fmt.Errorf("%s must not be negative", name))
+		}
+	}
+	if c.HTTP.MaxHeaderBytes <= 0 {
+		errs = append(errs, errors.New("http.max_header_bytes must be positive"))
+	}
+	if c.HTTP.MaxImportBytes <= 0 {
+		errs = append(errs, errors.New("http.max_import_bytes must be positive"))
+	}
+	if c.HTTP.CursorSecret == placeholderSecret {
+		errs = append(errs, errors.New("http.cursor_secret is the published placeholder, set a random value or leave it empty"))
+	}
+	switch s := c.Auth.JWTSecret; {
+	case s == placeholderSecret:
+		errs = append(errs, errors.New("auth.jwt_secret is the published placeholder, set a random value"))
+	case s != "" && len(s) < MinJWTSecretLen:
+		errs = append(errs, fmt.Errorf("auth.jwt_secret must be at least %d bytes", MinJWTSecretLen))
+	}
+	switch c.Storage.Kind {
+	case StorageMemory:
+	case StoragePostgres:
+		if c.Storage.DatabaseURL == "" {
+			errs = append(errs, errors.New("storage.database_url is required for postgres storage"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("storage.kind %q: want %s or %s", c.Storage.Kind, StoragePostgres, StorageMemory))
+	}
+	if c.Tracing.Enabled {
+		if c.Tracing.Exporter != ExporterStdout && c.Tracing.Exporter != ExporterOTLP {
+			errs = append(errs, fmt.Errorf("tracing.exporter %q: want %s or %s", c.Tracing.Exporter, ExporterStdout, ExporterOTLP))
+		}
+		if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+			errs = append(errs, errors.New("tracing.sample_ratio must be within [0, 1]"))
+		}
+	}
+	if c.Auth.Enabled {
+		if !c.Auth.JWT() && !c.Auth.APIKeys {
+			errs = append(errs, errors.New("auth requires api_keys, jwt_secret, jwt_public_key_file or jwks_file"))
+		}
+		if !c.Auth.JWT() && c.Storage.Kind == StorageMemory {
+			errs = append(errs, errors.New("auth with api_keys only needs postgres storage, memory storage cannot hold an issued admin key"))
+		}
+		if c.Auth.UserClaim == "" {
+			errs = append(errs, errors.New("auth.user_claim is required"))
+		}
+	}
+	if c.RateLimit.Enabled {
+		limits := map[string]Limit{"rate_limit.default": c.RateLimit.Default, "rate_limit.auth": c.RateLimit.Auth}
+		for route, l := range c.RateLimit.Routes {
+			limits["rate_limit.routes["+route+"]"] = l
+		}
+		for name, l := range limits {
+			if l.RPS <= 0 || l.Burst < 1 {
+				errs = append(errs, fmt.Errorf("%s: rps must be > 0 and burst >= 1", name))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ParseLevel ...
+func ParseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(s) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info", "":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return slog.LevelInfo, fmt.Errorf("log_level %q: want debug, info, warn or error", s)
+}
+
+// Level ...
+func (c Config) Level() slog.Level {
+	l, _ := ParseLevel(c.LogLevel)
+	return l
+}
+
+const redacted = "***"
+
+// dsnPassword matches the password in a key=value Postgres DSN.
+var dsnPassword = regexp.MustCompile(`(password=)('[^']*'|\S+)`)
+
+// Redacted returns a copy of c with secrets masked.
+func (c Config) Redacted() Config {
+	if c.HTTP.CursorSecret != "" {
+		c.HTTP.CursorSecret = redacted
+	}
+	c.Storage.DatabaseURL = redactDSN(c.Storage.DatabaseURL)
+	if c.Auth.JWTSecret != "" {
+		c.Auth.JWTSecret = redacted
+	}
+	return c
+}
+
+// redactDSN ...
+func redactDSN(dsn string) string {
+	if u, err := url.Parse(dsn); err == nil && u.Scheme != "" {
+		return u.Redacted()
+	}
+	return dsnPassword.ReplaceAllString(dsn, "${1}"+redacted)
+}
+
+// LogValue logs the redacted configuration.
+func (c Config) LogValue() slog.Value {
+	r := c.Redacted()
+	return slog.GroupValue(
+		slog.String("log_level", r.LogLevel),
+		slog.Group("http",
+			slog.String("addr", r.HTTP.Addr),
+			slog.Duration("read_timeout", r.HTTP.ReadTimeout),
+			slog.Duration("read_header_timeout", r.HTTP.ReadHeaderTimeout),
+			slog.Duration("write_timeout", r.HTTP.WriteTimeout),
+			slog.Duration("idle_timeout", r.HTTP.IdleTimeout),
+			slog.Duration("shutdown_timeout", r.HTTP.ShutdownTimeout),
+			slog.Duration("ready_timeout", r.HTTP.ReadyTimeout),
+			slog.Int("max_header_bytes", r.HTTP.MaxHeaderBytes),
+			slog.Int("max_import_bytes", r.HTTP.MaxImportBytes),
+			slog.Duration("export_timeout", r.HTTP.ExportTimeout),
+			slog.String("cursor_secret", r.HTTP.CursorSecret),
+		),
+		slog.Group("storage",
+			slog.String("kind", r.Storage.Kind),
+			slog.String("database_url", r.Storage.DatabaseURL),
+			slog.Duration("query_timeout", r.Storage.QueryTimeout),
+			slog.Bool("migrate_on_start", r.Storage.MigrateOnStart),
+		),
+		slog.Group("tracing",
+			slog.Bool("enabled", r.Tracing.Enabled),
+			slog.String("exporter", r.Tracing.Exporter),
+			slog.String("endpoint", r.Tracing.Endpoint),
+			slog.Float64("sample_ratio", r.Tracing.SampleRatio),
+		),
+		slog.Group("auth",
+			slog.Bool("enabled", r.Auth.Enabled),
+			slog.Bool("api_keys", r.Auth.APIKeys),
+			slog.String("jwt_secret", r.Auth.JWTSecret),
+			slog.String("jwt_public_key_file", r.Auth.JWTPublicKeyFile),
+			slog.String("jwks_file", r.Auth.JWKSFile),
+			slog.String("issuer", r.Auth.Issuer),
+			slog.String("audience", r.Auth.Audience),
+		),
+		slog.Group("rate_limit",
+			slog.Bool("enabled", r.RateLimit.Enabled),
+			slog.Float64("rps", r.RateLimit.Default.RPS),
+			slog.Int("burst", r.RateLimit.Default.Burst),
+			slog.Float64("auth_rps", r.RateLimit.Auth.RPS),
+			slog.Int("auth_burst", r.RateLimit.Auth.Burst),
+			slog.Int("routes", len(r.RateLimit.Routes)),
+		),
+	)
+}
@@ -0,0 +1,176 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func env(m map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		v, ok := m[key]
+		return v, ok
+	}
+}
+
+func writeFile(t *testing.T, name, body string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(p, []byte(body), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestLoadDefaults(t *testing.T) {
+	c, err := load("", env(map[string]string{"STORAGE": "mem"}))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	want := Default()
+	want.Storage.Kind = StorageMemory
+	if !reflect.DeepEqual(c, want) {
+		t.Fatalf("got %+v\nwant %+v", c, want)
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	yml := writeFile(t, "config.yaml", `
+log_level: warn
+http:
+  addr: ":9000"
+  write_timeout: 10s
+storage:
+  database_url: host=db password=secret
+  query_timeout: 2s
+`)
+	c, err := load(yml, env(map[string]string{
+		"BIND_ADDR":        ":9090",
+		"DB_QUERY_TIMEOUT": "3s",
+	}))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if c.LogLevel != "warn" || c.HTTP.WriteTimeout != 10*time.Second {
+		t.Fatalf("file values not applied: %+v", c)
+	}
+	if c.HTTP.Addr != ":9090" || c.Storage.QueryTimeout != 3*time.Second {
+		t.Fatalf("env did not override file: %+v", c)
+	}
+	if c.HTTP.IdleTimeout != Default().HTTP.IdleTimeout {
+		t.Fatalf("IdleTimeout: got %v", c.HTTP.IdleTimeout)
+	}
+
+	js := writeFile(t, "config.json", `{"storage": {"kind": "memory", "query_timeout": "1s"}}`)
+	c, err = load("", env(map[string]string{"CONFIG_FILE": js}))
+	if err != nil {
+		t.Fatalf("load json: %v", err)
+	}
+	if c.Storage.Kind != StorageMemory || c.Storage.QueryTimeout != time.Second {
+		t.Fatalf("json values not applied: %+v", c)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	_, err := load("", env(map[string]string{
+		"BIND_ADDR":        "8080",
+		"LOG_LEVEL":        "loud",
+		"DB_QUERY_TIMEOUT": "soon",
+	}))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	for _, want := range []string{"DB_QUERY_TIMEOUT"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("error %q does not mention %s", err, want)
+		}
+	}
+
+	_, err = load("", env(map[string]string{"BIND_ADDR": "8080", "LOG_LEVEL": "loud"}))
+	for _, want := range []string{"http.addr", "log_level", "database_url"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("error %v does not mention %s", err, want)
+		}
+	}
+
+	_, err = load("", env(map[string]string{
+		"STORAGE":              "memory",
+		"TRACING_ENABLED":      "true",
+		"TRACING_EXPORTER":     "zipkin",
+		"TRACING_SAMPLE_RATIO": "2",
+	}))
+	for _, want := range []string{"tracing.exporter", "tracing.sample_ratio"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("error %v does not mention %s", err, want)
+		}
+	}
+
+	_, err = load("", env(map[string]string{"STORAGE": "memory", "CURSOR_SECRET": "change-me"}))
+	if err == nil || !strings.Contains(err.Error(), "http.cursor_secret") {
+		t.Fatalf("placeholder cursor secret: got %v", err)
+	}
+
+	for secret, want := range map[string]string{"change-me": "placeholder", "short": "at least 32 bytes"} {
+		_, err = load("", env(map[string]string{"STORAGE": "memory", "AUTH_ENABLED": "true", "AUTH_JWT_SECRET": secret}))
+		if err == nil || !strings.Contains(err.Error(), "auth.jwt_secret") || !strings.Contains(err.Error(), want) {
+			t.Fatalf("jwt secret %q: got %v", secret, err)
+		}
+	}
+
+	_, err = load("", env(map[string]string{"STORAGE": "memory", "AUTH_ENABLED": "true"}))
+	if err == nil || !strings.Contains(err.Error(), "api_keys") {
+		t.Fatalf("auth without keys: got %v", err)
+	}
+	if _, err := load("", env(map[string]string{"STORAGE": "postgres", "DATABASE_URL": "postgres://db", "AUTH_ENABLED": "true", "AUTH_API_KEYS": "true"})); err != nil {
+		t.Fatalf("auth with api keys only: %v", err)
+	}
+	// No admin key can ever exist in memory storage, so nobody could get in.
+	_, err = load("", env(map[string]string{"STORAGE": "memory", "AUTH_ENABLED": "true", "AUTH_API_KEYS": "true"}))
+	if err == nil || !strings.Contains(err.Error(), "api_keys only") {
+		t.Fatalf("api keys only in memory: got %v", err)
+	}
+
+	_, err = load("", env(map[string]string{"STORAGE": "memory", "RATE_LIMIT_ENABLED": "true", "RATE_LIMIT_RPS": "0"}))
+	if err == nil || !strings.Contains(err.Error(), "rate_limit.default") {
+		t.Fatalf("zero rps: got %v", err)
+	}
+	_, err = load("", env(map[string]string{"STORAGE": "memory", "RATE_LIMIT_ENABLED": "true", "RATE_LIMIT_AUTH_BURST": "0"}))
+	if err == nil || !strings.Contains(err.Error(), "rate_limit.auth") {
+		t.Fatalf("zero auth burst: got %v", err)
+	}
+	yml := writeFile(t, "config.yaml", "rate_limit:\n  enabled: true\n  routes:\n    /service:\n      rps: 5\n")
+	if _, err := load(yml, env(map[string]string{"STORAGE": "memory"})); err == nil || !strings.Contains(err.Error(), "rate_limit.routes[/service]") {
+		t.Fatalf("route without burst: got %v", err)
+	}
+
+	yml = writeFile(t, "config.yaml", "rate_limit:\n  routes:\n    /service/summary: {rps: 2, burst: 3}\n")
+	c, err := load(yml, env(map[string]string{"STORAGE": "memory"}))
+	if err != nil || len(c.RateLimit.Routes) != 1 || c.RateLimit.Routes["/service/summary"] != (Limit{RPS: 2, Burst: 3}) {
+		t.Fatalf("routes must replace the defaults: %+v, %v", c.RateLimit.Routes, err)
+	}
+
+	yml = writeFile(t, "config.yaml", "htp:\n  addr: \":1\"\n")
+	if _, err := load(yml, env(nil)); err == nil {
+		t.Fatal("expected error for unknown key")
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	for dsn, want := range map[string]string{
+		"host=db user=postgres password=secret port=5432": "host=db user=postgres password=*** port=5432",
+		"postgres://postgres:secret@db:5432/app":          "postgres://postgres:xxxxx@db:5432/app",
+		"postgres://db:5432/app":                          "postgres://db:5432/app",
+	} {
+		c := Default()
+		c.Storage.DatabaseURL = dsn
+		c.HTTP.CursorSecret = "s3"
+		c.Auth.JWTSecret = "jwt"
+		r := c.Redacted()
+		if r.Storage.DatabaseURL != want || r.HTTP.CursorSecret != "***" || r.Auth.JWTSecret != "***" {
+			t.Fatalf("Redacted(%q): got %q, %q", dsn, r.Storage.DatabaseURL, r.HTTP.CursorSecret)
+		}
+	}
+}
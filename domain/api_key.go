@@ -0,0 +1,120 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// API key scopes. Read allows GET requests, write every other method on /service,
+// admin allows the /admin endpoints and access to every user's services.
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+	ScopeAdmin = "admin"
+)
+
+// Scopes lists every valid scope.
+var Scopes = []string{ScopeRead, ScopeWrite, ScopeAdmin}
+
+// ErrAPIKeyNotFound ...
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+// APIKey is a stored key. Only the SHA-256 hash of the secret is persisted.
+type APIKey struct {
+	ID     uuid.UUID `json:"id" example:"0b7cf43e-4b7a-4f5e-8c39-3e36f1a8d3a4"`
+	Name   string    `json:"name" example:"billing-sync"`
+	Prefix string    `json:"prefix" example:"sk_Zx81Q"`
+	Hash   string    `json:"-"`
+	// UserID restricts the key to one user's services; nil keys see every user.
+	UserID     *uuid.UUID `json:"user_id,omitempty"`
+	Scopes     []string   `json:"scopes" example:"read,write"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// HasScope ...
+func (k *APIKey) HasScope(scope string) bool {
+	return slices.Contains(k.Scopes, scope)
+}
+
+// Active reports whether the key is neither revoked nor expired at now.
+func (k *APIKey) Active(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+// APIKeyRepository stores API keys by the hash of their secret.
+// Create fills ID and CreatedAt; Revoke keeps the first revocation time.
+type APIKeyRepository interface {
+	Create(ctx context.Context, k *APIKey) error
+	GetByHash(ctx context.Context, hash string) (*APIKey, error)
+	List(ctx context.Context) ([]APIKey, error)
+	Revoke(ctx context.Context, id uuid.UUID) error
+	Touch(ctx context.Context, id uuid.UUID, at time.Time) error
+}
+
+// CreateAPIKeyRequest ...
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" example:"billing-sync"`
+	UserID    string     `json:"user_id,omitempty" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
+	Scopes    []string   `json:"scopes" example:"read"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// ToAPIKey validates the request against now and builds a key without secret.
+func (in CreateAPIKeyRequest) ToAPIKey(now time.Time) (*APIKey, error) {
+	var verr ValidationError
+	k := &APIKey{Name: strings.TrimSpace(in.Name), ExpiresAt: in.ExpiresAt}
+	if k.Name == "" {
+		verr.Add("name", "required")
+	} else if len(k.Name) > 128 {
+		verr.Add("name", "must be at most 128 characters")
+	}
+	if in.UserID != "" {
+		id, err := uuid.Parse(in.UserID)
+		if err != nil {
+			verr.Add("user_id", "must be a uuid")
+		} else {
+			k.UserID = &id
+		}
+	}
+	if len(in.Scopes) == 0 {
+		verr.Add("scopes", "required")
+	}
+	for _, s := range in.Scopes {
+		if !slices.Contains(Scopes, s) {
+			verr.Add("scopes", "want read, write or admin")
+			break
+		}
+		if !slices.Contains(k.Scopes, s) {
+			k.Scopes = append(k.Scopes, s)
+		}
+	}
+	if in.UserID == "" && !slices.Contains(k.Scopes, ScopeAdmin) {
+		verr.Add("user_id", "required unless scopes include admin")
+	}
+	if in.ExpiresAt != nil && !in.ExpiresAt.After(now) {
+		verr.Add("expires_at", "must be in the future")
+	}
+	if err := verr.Err(); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// APIKeyCreated is returned once on issue; Key is the only copy of the secret.
+type APIKeyCreated struct {
+	APIKey
+	Key string `json:"key" example:"sk_Zx81Q..."`
+}
+
+// APIKeyList ...
+type APIKeyList struct {
+	Items []APIKey `json:"items"`
+}
@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+)
+
+// BatchSaver is implemented by repositories that can save many services at once.
+// SaveBatch is all or nothing: on error no service is saved and the error is a
+// *BatchError when a single service caused it.
+type BatchSaver interface {
+	SaveBatch(ctx context.Context, ss []*Service) ([]int, error)
+}
+
+// BatchError reports the service of a batch that failed.
+type BatchError struct {
+	Index int
+	Err   error
+}
+
+// Error ...
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("batch item %d: %v", e.Index, e.Err)
+}
+
+// Unwrap ...
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// Batch operation kinds.
+const (
+	OpCreate = "create"
+	OpUpdate = "update"
+	OpDelete = "delete"
+)
+
+// MaxBatchOperations caps the operations of one batch request.
+const MaxBatchOperations = 1000
+
+// BatchOperation is one item of a batch. Create and update carry data,
+// update and delete the service id.
+type BatchOperation struct {
+	Op   string          `json:"op" enums:"create,update,delete" example:"update"`
+	ID   int             `json:"id,omitempty" example:"42"`
+	Data *CreatedRequest `json:"data,omitempty"`
+}
+
+// BatchRequest runs its operations in order. Atomic ones are all applied or
+// none; otherwise every operation is applied on its own (best effort).
+type BatchRequest struct {
+	Atomic     bool             `json:"atomic"`
+	Operations []BatchOperation `json:"operations"`
+}
+
+// Validate checks the batch itself; operations are checked as they run.
+func (b BatchRequest) Validate() error {
+	var verr ValidationError
+	switch n := len(b.Operations); {
+	case n == 0:
+		verr.Add("operations", "required")
+	case n > MaxBatchOperations:
+		verr.Add("operations", fmt.Sprintf("at most %d", MaxBatchOperations))
+	}
+	return verr.Err()
+}
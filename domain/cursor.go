@@ -0,0 +1,115 @@
+package domain
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidCursor ...
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor marks a position in a sorted service list.
+// Backward cursors page towards the start of the list.
+type Cursor struct {
+	SortBy   string `json:"s"`
+	SortDir  string `json:"d"`
+	Value    string `json:"v"`
+	ID       int    `json:"i"`
+	Backward bool   `json:"b,omitempty"`
+}
+
+// CursorAt returns the cursor positioned at s for the given sort.
+func CursorAt(s *Service, sortBy, sortDir string, backward bool) Cursor {
+	c := Cursor{SortBy: sortBy, SortDir: sortDir, ID: s.id, Backward: backward}
+	switch sortBy {
+	case "service_price":
+		c.Value = strconv.Itoa(s.price)
+	case "service_name":
+		c.Value = s.name
+	default:
+		c.Value = s.startDate.Format("2006-01-02")
+	}
+	return c
+}
+
+// CursorCodec signs cursors so clients cannot forge them.
+type CursorCodec struct {
+	key []byte
+}
+
+// NewCursorCodec ...
+func NewCursorCodec(key []byte) *CursorCodec {
+	return &CursorCodec{key: key}
+}
+
+// Encode returns an opaque token for c.
+func (cc *CursorCodec) Encode(c Cursor) string {
+	payload, _ := json.Marshal(c)
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString(payload) + "." + enc.EncodeToString(cc.sign(payload))
+}
+
+// Decode verifies the token signature and returns its cursor.
+func (cc *CursorCodec) Decode(token string) (Cursor, error) {
+	var c Cursor
+	enc := base64.RawURLEncoding
+	p, s, ok := strings.Cut(token, ".")
+	if !ok {
+		return c, ErrInvalidCursor
+	}
+	payload, err := enc.DecodeString(p)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	sig, err := enc.DecodeString(s)
+	if err != nil || !hmac.Equal(sig, cc.sign(payload)) {
+		return c, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return c, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// sign ...
+func (cc *CursorCodec) sign(payload []byte) []byte {
+	m := hmac.New(sha256.New, cc.key)
+	m.Write(payload)
+	return m.Sum(nil)
+}
+
+// NewListPage builds a page from up to f.Limit+1 services fetched in the cursor direction.
+func NewListPage(fetched []*Service, f ListFilterService) ListResult {
+	backward := f.Cursor != nil && f.Cursor.Backward
+	more := len(fetched) > f.Limit
+	if more {
+		fetched = fetched[:f.Limit]
+	}
+	if backward {
+		for i, j := 0, len(fetched)-1; i < j; i, j = i+1, j-1 {
+			fetched[i], fetched[j] = fetched[j], fetched[i]
+		}
+	}
+
+	out := ListResult{Items: make([]ServiceResponse, 0, len(fetched))}
+	for _, s := range fetched {
+		out.Items = append(out.Items, NewServiceResponse(s))
+	}
+	if len(fetched) == 0 {
+		return out
+	}
+	if more || backward {
+		next := CursorAt(fetched[len(fetched)-1], f.SortBy, f.SortDir, false)
+		out.Next = &next
+	}
+	if (backward && more) || (!backward && f.Cursor != nil) {
+		prev := CursorAt(fetched[0], f.SortBy, f.SortDir, true)
+		out.Prev = &prev
+	}
+	return out
+}
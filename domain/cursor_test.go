@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestCursorCodec(t *testing.T) {
+	cc := NewCursorCodec([]byte("secret"))
+	want := Cursor{SortBy: "service_price", SortDir: "asc", Value: "400", ID: 7, Backward: true}
+
+	tok := cc.Encode(want)
+	got, err := cc.Decode(tok)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if got != want {
+		t.Fatalf("mismatch:\n got: %#v\nwant: %#v", got, want)
+	}
+
+	forged := Cursor{SortBy: "service_price", SortDir: "asc", Value: "0", ID: 1}
+	payload := strings.SplitN(cc.Encode(forged), ".", 2)[0]
+	sig := strings.SplitN(tok, ".", 2)[1]
+	for _, bad := range []string{"", "abc", payload + "." + sig, tok + "x"} {
+		if _, err := cc.Decode(bad); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("Decode(%q): got err=%v want ErrInvalidCursor", bad, err)
+		}
+	}
+	if _, err := NewCursorCodec([]byte("other")).Decode(tok); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("Decode with other key: got err=%v want ErrInvalidCursor", err)
+	}
+}
+
+func TestNewListPage(t *testing.T) {
+	services := make([]*Service, 4)
+	for i := range services {
+		services[i] = NewService("S", 100*(i+1), uuid.New(), time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC))
+		services[i].SetMeta(i+1, time.Now(), time.Now())
+	}
+	f := ListFilterService{SortBy: "service_price", SortDir: "asc", Limit: 3}
+
+	first := NewListPage(services, f)
+	if len(first.Items) != 3 || first.Next == nil || first.Prev != nil {
+		t.Fatalf("first page: items=%d next=%v prev=%v", len(first.Items), first.Next, first.Prev)
+	}
+	if first.Next.ID != 3 || first.Next.Value != "300" {
+		t.Fatalf("first page next: %#v", first.Next)
+	}
+
+	f.Cursor = first.Next
+	last := NewListPage(services[3:], f)
+	if len(last.Items) != 1 || last.Next != nil || last.Prev == nil || !last.Prev.Backward {
+		t.Fatalf("last page: items=%d next=%v prev=%v", len(last.Items), last.Next, last.Prev)
+	}
+
+	// backward fetch returns rows in reverse order
+	f.Cursor = last.Prev
+	back := NewListPage([]*Service{services[2], services[1], services[0]}, f)
+	if len(back.Items) != 3 || back.Items[0].ID != 1 || back.Next == nil || back.Prev != nil {
+		t.Fatalf("backward page: %#v", back)
+	}
+}
@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+)
+
+// Sentinel errors every ServiceRepository implementation returns.
+var (
+	ErrNotFound   = errors.New("service not found")
+	ErrInvalidID  = errors.New("invalid service id")
+	ErrConflict   = errors.New("service conflict")
+	ErrValidation = errors.New("validation failed")
+)
+
+// ErrForbidden is returned when the caller may not act on another user's services.
+var ErrForbidden = errors.New("forbidden")
+
+// FieldError ...
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError collects rule violations per field.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// Add ...
+func (e *ValidationError) Add(field, msg string) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: msg})
+}
+
+// Has reports whether field already has a violation.
+func (e *ValidationError) Has(field string) bool {
+	for _, f := range e.Fields {
+		if f.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+// Err returns nil when no violation was added.
+func (e *ValidationError) Err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Error ...
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		msgs = append(msgs, f.Field+": "+f.Message)
+	}
+	return ErrValidation.Error() + ": " + strings.Join(msgs, "; ")
+}
+
+// Unwrap ...
+func (e *ValidationError) Unwrap() error {
+	return ErrValidation
+}
@@ -0,0 +1,13 @@
+package domain
+
+import "context"
+
+// Pinger is implemented by repositories that can check their backend is reachable.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// SchemaVersioner is implemented by repositories backed by a migrated schema.
+type SchemaVersioner interface {
+	SchemaVersion(ctx context.Context) (version int64, dirty bool, err error)
+}
@@ -0,0 +1,567 @@
+// Package repotest checks that every domain.ServiceRepository implementation
+// behaves the same way against a fixed dataset.
+package repotest
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/animans/REST-API-test-task/domain"
+	"github.com/google/uuid"
+)
+
+// Factory returns an empty repository, closed by the factory via t.Cleanup.
+type Factory func(t *testing.T) domain.ServiceRepository
+
+// Users of the fixed dataset.
+var (
+	UserA = uuid.MustParse("00000000-0000-0000-0000-00000000000a")
+	UserB = uuid.MustParse("00000000-0000-0000-0000-00000000000b")
+	UserC = uuid.MustParse("00000000-0000-0000-0000-00000000000c")
+)
+
+// fixture ...
+type fixture struct {
+	name  string
+	price int
+	user  uuid.UUID
+	start string
+	end   string
+}
+
+// dataset is saved in order, so ids are 1..5 on a fresh repository.
+var dataset = []fixture{
+	{"Yandex Plus", 400, UserA, "07-2024", ""},
+	{"Yandex Music", 200, UserA, "01-2024", "03-2024"},
+	{"GPT Plus", 2000, UserB, "02-2024", "06-2024"},
+	{"Netflix", 800, UserB, "10-2024", ""},
+	{"Spotify", 300, UserC, "05-2023", "12-2023"},
+}
+
+// Run executes the whole suite, each subtest on a fresh repository.
+func Run(t *testing.T, newRepo Factory) {
+	t.Run("SaveGet", func(t *testing.T) { testSaveGet(t, newRepo(t)) })
+	t.Run("UpdateByID", func(t *testing.T) { testUpdate(t, newRepo(t)) })
+	t.Run("DeleteByID", func(t *testing.T) { testDelete(t, newRepo(t)) })
+	t.Run("NotFound", func(t *testing.T) { testNotFound(t, newRepo(t)) })
+	t.Run("ListFilters", func(t *testing.T) { testListFilters(t, seed(t, newRepo(t))) })
+	t.Run("ListSort", func(t *testing.T) { testListSort(t, seed(t, newRepo(t))) })
+	t.Run("ListPages", func(t *testing.T) { testListPages(t, seed(t, newRepo(t))) })
+	t.Run("NameOrder", func(t *testing.T) { testNameOrder(t, newRepo(t)) })
+	t.Run("NameWildcards", func(t *testing.T) { testNameWildcards(t, newRepo(t)) })
+	t.Run("IterateByFilter", func(t *testing.T) { testIterate(t, seed(t, newRepo(t))) })
+	t.Run("SumByFilter", func(t *testing.T) { testSumByFilter(t, seed(t, newRepo(t))) })
+	t.Run("SumByMonth", func(t *testing.T) { testSumByMonth(t, seed(t, newRepo(t))) })
+	t.Run("SumByGroup", func(t *testing.T) { testSumByGroup(t, seed(t, newRepo(t))) })
+	t.Run("SumByGroupTies", func(t *testing.T) { testSumByGroupTies(t, newRepo(t)) })
+	t.Run("SaveBatch", func(t *testing.T) { testSaveBatch(t, newRepo(t)) })
+	t.Run("WithTx", func(t *testing.T) { testWithTx(t, seed(t, newRepo(t))) })
+}
+
+// Month parses an MM-YYYY month.
+func Month(t *testing.T, s string) *time.Time {
+	t.Helper()
+	if s == "" {
+		return nil
+	}
+	m, err := time.Parse(domain.MonthLayout, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &m
+}
+
+// newService ...
+func newService(t *testing.T, f fixture) *domain.Service {
+	t.Helper()
+	s := domain.NewService(f.name, f.price, f.user, *Month(t, f.start))
+	s.SetEndDate(Month(t, f.end))
+	return s
+}
+
+// seed saves the dataset and returns repo.
+func seed(t *testing.T, repo domain.ServiceRepository) domain.ServiceRepository {
+	t.Helper()
+	for _, f := range dataset {
+		if _, err := repo.Save(t.Context(), newService(t, f)); err != nil {
+			t.Fatalf("Save(%s): %v", f.name, err)
+		}
+	}
+	return repo
+}
+
+func testSaveGet(t *testing.T, repo domain.ServiceRepository) {
+	s := newService(t, dataset[1])
+	id, err := repo.Save(t.Context(), s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id < 1 || s.GetID() != id || s.GetCreatedAt().IsZero() || s.GetUpdatedAt().IsZero() {
+		t.Fatalf("Save meta: id=%d service=%d created=%s", id, s.GetID(), s.GetCreatedAt())
+	}
+
+	got, err := repo.GetByID(t.Context(), strconv.Itoa(id))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := domain.NewServiceResponse(s)
+	have := domain.NewServiceResponse(got)
+	want.CreatedAt, want.UpdatedAt, have.CreatedAt, have.UpdatedAt = time.Time{}, time.Time{}, time.Time{}, time.Time{}
+	if have != want {
+		t.Fatalf("GetByID mismatch:\n got: %#v\nwant: %#v", have, want)
+	}
+
+	bad := newService(t, fixture{"Bad", 100, UserA, "05-2024", "04-2024"})
+	if _, err := repo.Save(t.Context(), bad); !errors.Is(err, domain.ErrValidation) {
+		t.Fatalf("Save end before start: got err=%v want ErrValidation", err)
+	}
+}
+
+func testSaveBatch(t *testing.T, repo domain.ServiceRepository) {
+	b, ok := repo.(domain.BatchSaver)
+	if !ok {
+		t.Skip("not a domain.BatchSaver")
+	}
+	bad := []*domain.Service{newService(t, dataset[0]), newService(t, fixture{"Bad", 100, UserA, "05-2024", "04-2024"})}
+	_, err := b.SaveBatch(t.Context(), bad)
+	var berr *domain.BatchError
+	if !errors.As(err, &berr) || berr.Index != 1 || !errors.Is(err, domain.ErrValidation) {
+		t.Fatalf("SaveBatch invalid: got err=%v want BatchError at 1", err)
+	}
+	if res := list(t, repo, domain.ListFilterService{}); len(res.Items) != 0 {
+		t.Fatalf("SaveBatch invalid saved %d services", len(res.Items))
+	}
+
+	ss := []*domain.Service{newService(t, dataset[0]), newService(t, dataset[1])}
+	ids, err := b.SaveBatch(t.Context(), ss)
+	if err != nil || len(ids) != 2 || ids[0] == ids[1] {
+		t.Fatalf("SaveBatch: ids=%v err=%v", ids, err)
+	}
+	for i, s := range ss {
+		got, err := repo.GetByID(t.Context(), strconv.Itoa(ids[i]))
+		if err != nil || s.GetID() != ids[i] || got.GetName() != s.GetName() {
+			t.Fatalf("SaveBatch %d: id=%d got=%v err=%v", i, s.GetID(), got, err)
+		}
+	}
+}
+
+func testWithTx(t *testing.T, repo domain.ServiceRepository) {
+	ctx := t.Context()
+	boom := errors.New("boom")
+	err := repo.WithTx(ctx, func(tx domain.ServiceRepository) error {
+		if _, err := tx.Save(ctx, newService(t, fixture{"Okko", 100, UserC, "01-2025", ""})); err != nil {
+			return err
+		}
+		if err := tx.DeleteByID(ctx, "1"); err != nil {
+			return err
+		}
+		if _, err := tx.GetByID(ctx, "1"); !errors.Is(err, domain.ErrNotFound) {
+			t.Errorf("GetByID in tx after delete: %v", err)
+		}
+		return boom
+	})
+	if !errors.Is(err, boom) {
+		t.Fatalf("WithTx rollback: got err=%v want boom", err)
+	}
+	if res := list(t, repo, domain.ListFilterService{}); len(res.Items) != len(dataset) {
+		t.Fatalf("WithTx rollback kept changes: %v", names(res))
+	}
+
+	var id int
+	err = repo.WithTx(ctx, func(tx domain.ServiceRepository) error {
+		var err error
+		if id, err = tx.Save(ctx, newService(t, fixture{"Okko", 100, UserC, "01-2025", ""})); err != nil {
+			return err
+		}
+		s := newService(t, fixture{"Yandex Plus Family", 500, UserA, "07-2024", ""})
+		if err := tx.UpdateByID(ctx, "1", s); err != nil {
+			return err
+		}
+		return tx.DeleteByID(ctx, "2")
+	})
+	if err != nil {
+		t.Fatalf("WithTx commit: %v", err)
+	}
+	if got, err := repo.GetByID(ctx, strconv.Itoa(id)); err != nil || got.GetName() != "Okko" {
+		t.Fatalf("WithTx commit Save: got=%v err=%v", got, err)
+	}
+	if got, err := repo.GetByID(ctx, "1"); err != nil || got.GetName() != "Yandex Plus Family" {
+		t.Fatalf("WithTx commit UpdateByID: got=%v err=%v", got, err)
+	}
+	if _, err := repo.GetByID(ctx, "2"); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("WithTx commit DeleteByID: err=%v", err)
+	}
+}
+
+func testUpdate(t *testing.T, repo domain.ServiceRepository) {
+	s := newService(t, dataset[0])
+	id, err := repo.Save(t.Context(), s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	upd := newService(t, fixture{"Yandex Plus Family", 600, UserB, "08-2024", "12-2024"})
+	if err := repo.UpdateByID(t.Context(), strconv.Itoa(id), upd); err != nil {
+		t.Fatal(err)
+	}
+	got, err := repo.GetByID(t.Context(), strconv.Itoa(id))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := domain.NewServiceResponse(got)
+	if r.ID != id || r.Name != "Yandex Plus Family" || r.Price != 600 || r.Uuid != UserB.String() ||
+		r.StartDate != "08-2024" || r.EndDate != "12-2024" {
+		t.Fatalf("UpdateByID: %#v", r)
+	}
+	if got.GetUpdatedAt().Before(got.GetCreatedAt()) {
+		t.Fatalf("updated_at %s before created_at %s", got.GetUpdatedAt(), got.GetCreatedAt())
+	}
+}
+
+func testDelete(t *testing.T, repo domain.ServiceRepository) {
+	id, err := repo.Save(t.Context(), newService(t, dataset[0]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.DeleteByID(t.Context(), strconv.Itoa(id)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.GetByID(t.Context(), strconv.Itoa(id)); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("GetByID after delete: got err=%v want ErrNotFound", err)
+	}
+	if err := repo.DeleteByID(t.Context(), strconv.Itoa(id)); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("second DeleteByID: got err=%v want ErrNotFound", err)
+	}
+}
+
+func testNotFound(t *testing.T, repo domain.ServiceRepository) {
+	s := newService(t, dataset[0])
+	if _, err := repo.GetByID(t.Context(), "999999"); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("GetByID: got err=%v want ErrNotFound", err)
+	}
+	if err := repo.UpdateByID(t.Context(), "999999", s); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("UpdateByID: got err=%v want ErrNotFound", err)
+	}
+	if err := repo.DeleteByID(t.Context(), "999999"); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("DeleteByID: got err=%v want ErrNotFound", err)
+	}
+	for _, id := range []string{"", "abc", "0", "-1"} {
+		if _, err := repo.GetByID(t.Context(), id); !errors.Is(err, domain.ErrInvalidID) {
+			t.Errorf("GetByID(%q): got err=%v want ErrInvalidID", id, err)
+		}
+		if err := repo.UpdateByID(t.Context(), id, s); !errors.Is(err, domain.ErrInvalidID) {
+			t.Errorf("UpdateByID(%q): got err=%v want ErrInvalidID", id, err)
+		}
+		if err := repo.DeleteByID(t.Context(), id); !errors.Is(err, domain.ErrInvalidID) {
+			t.Errorf("DeleteByID(%q): got err=%v want ErrInvalidID", id, err)
+		}
+	}
+}
+
+// names lists item names of a page.
+func names(res domain.ListResult) []string {
+	out := make([]string, 0, len(res.Items))
+	for _, it := range res.Items {
+		out = append(out, it.Name)
+	}
+	return out
+}
+
+// list ...
+func list(t *testing.T, repo domain.ServiceRepository, f domain.ListFilterService) domain.ListResult {
+	t.Helper()
+	if f.SortBy == "" {
+		f.SortBy, f.SortDir = "service_price", "asc"
+	}
+	if f.Limit == 0 {
+		f.Limit = 100
+	}
+	res, err := repo.ListByFilter(t.Context(), f)
+	if err != nil {
+		t.Fatalf("ListByFilter(%+v): %v", f, err)
+	}
+	return res
+}
+
+func testListFilters(t *testing.T, repo domain.ServiceRepository) {
+	cases := []struct {
+		name string
+		f    domain.ListFilterService
+		want string
+	}{
+		{"all", domain.ListFilterService{}, "[Yandex Music Spotify Yandex Plus Netflix GPT Plus]"},
+		{"name_contains_case_insensitive", domain.ListFilterService{Name: "yandex"}, "[Yandex Music Yandex Plus]"},
+		{"price", domain.ListFilterService{Price: 400}, "[Yandex Plus]"},
+		{"user", domain.ListFilterService{Uuid: &UserB}, "[Netflix GPT Plus]"},
+		{"active_overlap", domain.ListFilterService{From: Month(t, "08-2024"), To: Month(t, "12-2024")}, "[Yandex Plus Netflix]"},
+		{"active_from", domain.ListFilterService{From: Month(t, "04-2024")}, "[Yandex Plus Netflix GPT Plus]"},
+		{"active_to", domain.ListFilterService{To: Month(t, "01-2024")}, "[Yandex Music Spotify]"},
+		{"combined", domain.ListFilterService{Name: "plus", From: Month(t, "01-2024"), To: Month(t, "03-2024")}, "[GPT Plus]"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := fmt.Sprint(names(list(t, repo, c.f))); got != c.want {
+				t.Fatalf("got=%s want=%s", got, c.want)
+			}
+		})
+	}
+}
+
+func testListSort(t *testing.T, repo domain.ServiceRepository) {
+	cases := []struct {
+		sortBy, dir string
+		want        string
+	}{
+		{"service_price", "asc", "[Yandex Music Spotify Yandex Plus Netflix GPT Plus]"},
+		{"service_price", "desc", "[GPT Plus Netflix Yandex Plus Spotify Yandex Music]"},
+		{"service_name", "asc", "[GPT Plus Netflix Spotify Yandex Music Yandex Plus]"},
+		{"service_created_at", "asc", "[Spotify Yandex Music GPT Plus Yandex Plus Netflix]"},
+		{"service_created_at", "desc", "[Netflix Yandex Plus GPT Plus Yandex Music Spotify]"},
+	}
+	for _, c := range cases {
+		t.Run(c.sortBy+"_"+c.dir, func(t *testing.T) {
+			res := list(t, repo, domain.ListFilterService{SortBy: c.sortBy, SortDir: c.dir})
+			if got := fmt.Sprint(names(res)); got != c.want {
+				t.Fatalf("got=%s want=%s", got, c.want)
+			}
+		})
+	}
+
+	res := list(t, repo, domain.ListFilterService{Limit: 2})
+	if got := fmt.Sprint(names(res)); got != "[Yandex Music Spotify]" || res.Next == nil {
+		t.Fatalf("limit 2: got=%s next=%v", got, res.Next)
+	}
+}
+
+func testIterate(t *testing.T, repo domain.ServiceRepository) {
+	collect := func(f domain.ListFilterService, max int) []string {
+		t.Helper()
+		var out []string
+		for s, err := range repo.IterateByFilter(t.Context(), f) {
+			if err != nil {
+				t.Fatalf("IterateByFilter(%+v): %v", f, err)
+			}
+			out = append(out, s.GetName())
+			if len(out) == max {
+				break
+			}
+		}
+		return out
+	}
+	if got := fmt.Sprint(collect(domain.ListFilterService{SortBy: "service_price", SortDir: "asc", Limit: 1}, 0)); got != "[Yandex Music Spotify Yandex Plus Netflix GPT Plus]" {
+		t.Fatalf("price asc: got=%s", got)
+	}
+	if got := fmt.Sprint(collect(domain.ListFilterService{Uuid: &UserA, SortBy: "service_created_at", SortDir: "asc"}, 0)); got != "[Yandex Music Yandex Plus]" {
+		t.Fatalf("user filter: got=%s", got)
+	}
+	if got := fmt.Sprint(collect(domain.ListFilterService{From: Month(t, "04-2024"), To: Month(t, "08-2024"), SortBy: "service_name", SortDir: "desc"}, 0)); got != "[Yandex Plus GPT Plus]" {
+		t.Fatalf("period filter: got=%s", got)
+	}
+	if got := collect(domain.ListFilterService{}, 2); len(got) != 2 {
+		t.Fatalf("early stop: got=%v", got)
+	}
+}
+
+func testListPages(t *testing.T, repo domain.ServiceRepository) {
+	for _, dir := range []string{"asc", "desc"} {
+		t.Run(dir, func(t *testing.T) {
+			f := domain.ListFilterService{SortBy: "service_created_at", SortDir: dir, Limit: 2, WithTotal: true}
+			var (
+				seen  []string
+				pages []domain.ListResult
+			)
+			for {
+				res := list(t, repo, f)
+				if res.Total == nil || *res.Total != len(dataset) {
+					t.Fatalf("total: got=%v want=%d", res.Total, len(dataset))
+				}
+				seen = append(seen, names(res)...)
+				pages = append(pages, res)
+				if res.Next == nil {
+					break
+				}
+				if len(pages) > len(dataset) {
+					t.Fatal("pagination does not terminate")
+				}
+				f.Cursor = res.Next
+			}
+			all := fmt.Sprint(names(list(t, repo, domain.ListFilterService{SortBy: "service_created_at", SortDir: dir})))
+			if fmt.Sprint(seen) != all || len(pages) != 3 {
+				t.Fatalf("pages: got=%v (%d pages) want=%s", seen, len(pages), all)
+			}
+
+			last := pages[len(pages)-1]
+			if last.Prev == nil {
+				t.Fatal("last page has no prev cursor")
+			}
+			f.Cursor = last.Prev
+			back := list(t, repo, f)
+			if fmt.Sprint(names(back)) != fmt.Sprint(names(pages[1])) || back.Next == nil || back.Prev == nil {
+				t.Fatalf("prev page: got=%v want=%v", names(back), names(pages[1]))
+			}
+		})
+	}
+}
+
+// testNameOrder pins byte order for names, so backends do not follow their own collation.
+func testNameOrder(t *testing.T, repo domain.ServiceRepository) {
+	for _, name := range []string{"banana", "Zebra", "apple", "Banana", "Apple"} {
+		if _, err := repo.Save(t.Context(), newService(t, fixture{name, 100, UserA, "01-2024", ""})); err != nil {
+			t.Fatalf("Save(%s): %v", name, err)
+		}
+	}
+	const want = "[Apple Banana Zebra apple banana]"
+	f := domain.ListFilterService{SortBy: "service_name", SortDir: "asc", Limit: 2}
+	if got := fmt.Sprint(names(list(t, repo, domain.ListFilterService{SortBy: f.SortBy, SortDir: f.SortDir}))); got != want {
+		t.Fatalf("list: got=%s want=%s", got, want)
+	}
+	var seen []string
+	for i := 0; i < 5; i++ {
+		res := list(t, repo, f)
+		seen = append(seen, names(res)...)
+		if res.Next == nil {
+			break
+		}
+		f.Cursor = res.Next
+	}
+	if got := fmt.Sprint(seen); got != want {
+		t.Fatalf("pages: got=%s want=%s", got, want)
+	}
+	var iterated []string
+	for s, err := range repo.IterateByFilter(t.Context(), domain.ListFilterService{SortBy: "service_name", SortDir: "asc"}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		iterated = append(iterated, s.GetName())
+	}
+	if got := fmt.Sprint(iterated); got != want {
+		t.Fatalf("iterate: got=%s want=%s", got, want)
+	}
+}
+
+// testNameWildcards checks that the name filter matches LIKE wildcards literally.
+func testNameWildcards(t *testing.T, repo domain.ServiceRepository) {
+	for _, name := range []string{"100% Music", "my_app", `C:\Apps`, "Netflix"} {
+		if _, err := repo.Save(t.Context(), newService(t, fixture{name, 100, UserA, "01-2024", ""})); err != nil {
+			t.Fatalf("Save(%s): %v", name, err)
+		}
+	}
+	for filter, want := range map[string]string{"%": "[100% Music]", "_": "[my_app]", `\`: `[C:\Apps]`, "0%": "[100% Music]", "y_a": "[my_app]"} {
+		if got := fmt.Sprint(names(list(t, repo, domain.ListFilterService{Name: filter}))); got != want {
+			t.Errorf("list %q: got=%s want=%s", filter, got, want)
+		}
+		res, err := repo.SumByFilter(t.Context(), domain.SumFilterService{Name: filter, From: Month(t, "01-2024"), To: Month(t, "01-2024")})
+		if err != nil || res.Total != 100 {
+			t.Errorf("sum %q: got=%+v, %v want total 100", filter, res, err)
+		}
+	}
+}
+
+func testSumByFilter(t *testing.T, repo domain.ServiceRepository) {
+	cases := []struct {
+		name string
+		f    domain.SumFilterService
+		want int
+	}{
+		{"window", domain.SumFilterService{From: Month(t, "01-2024"), To: Month(t, "06-2024")}, 600 + 10000},
+		{"name", domain.SumFilterService{Name: "plus", From: Month(t, "01-2024"), To: Month(t, "06-2024")}, 10000},
+		{"user", domain.SumFilterService{Uuid: &UserA, From: Month(t, "01-2024"), To: Month(t, "12-2024")}, 600 + 6*400},
+		{"started_before_window", domain.SumFilterService{From: Month(t, "11-2024"), To: Month(t, "12-2024")}, 2*400 + 2*800},
+		{"no_from", domain.SumFilterService{Uuid: &UserC, To: Month(t, "12-2024")}, 8 * 300},
+		{"empty", domain.SumFilterService{From: Month(t, "01-2020"), To: Month(t, "12-2020")}, 0},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := repo.SumByFilter(t.Context(), c.f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Total != c.want {
+				t.Fatalf("total: got=%d want=%d", got.Total, c.want)
+			}
+		})
+	}
+}
+
+func testSumByMonth(t *testing.T, repo domain.ServiceRepository) {
+	got, err := repo.SumByMonth(t.Context(), domain.SumFilterService{From: Month(t, "12-2023"), To: Month(t, "04-2024")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []domain.MonthSum{
+		{Month: "12-2023", Total: 300, Count: 1},
+		{Month: "01-2024", Total: 200, Count: 1},
+		{Month: "02-2024", Total: 2200, Count: 2},
+		{Month: "03-2024", Total: 2200, Count: 2},
+		{Month: "04-2024", Total: 2000, Count: 1},
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("got=%v want=%v", got, want)
+	}
+
+	got, err = repo.SumByMonth(t.Context(), domain.SumFilterService{Uuid: &UserA, From: Month(t, "06-2024"), To: Month(t, "07-2024")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []domain.MonthSum{{Month: "06-2024"}, {Month: "07-2024", Total: 400, Count: 1}}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("user: got=%v want=%v", got, want)
+	}
+}
+
+func testSumByGroup(t *testing.T, repo domain.ServiceRepository) {
+	f := domain.SumFilterService{From: Month(t, "01-2024"), To: Month(t, "12-2024"), GroupBy: domain.GroupByUser}
+	got, err := repo.SumByGroup(t.Context(), f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []domain.GroupSum{
+		{Key: UserB.String(), Total: 10000 + 3*800, Count: 2},
+		{Key: UserA.String(), Total: 600 + 6*400, Count: 2},
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("by user: got=%v want=%v", got, want)
+	}
+
+	f.GroupBy = domain.GroupByName
+	f.Name = "yandex"
+	got, err = repo.SumByGroup(t.Context(), f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []domain.GroupSum{
+		{Key: "Yandex Plus", Total: 6 * 400, Count: 1},
+		{Key: "Yandex Music", Total: 600, Count: 1},
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("by name: got=%v want=%v", got, want)
+	}
+
+	f.GroupBy = "service_price"
+	if _, err := repo.SumByGroup(t.Context(), f); !errors.Is(err, domain.ErrValidation) {
+		t.Fatalf("unknown group: got err=%v want ErrValidation", err)
+	}
+}
+
+// testSumByGroupTies orders groups with equal totals by key bytes, as NameOrder does for lists.
+func testSumByGroupTies(t *testing.T, repo domain.ServiceRepository) {
+	for _, name := range []string{"banana", "Zebra", "apple", "Banana"} {
+		if _, err := repo.Save(t.Context(), newService(t, fixture{name, 100, UserA, "01-2024", ""})); err != nil {
+			t.Fatalf("Save(%s): %v", name, err)
+		}
+	}
+	f := domain.SumFilterService{From: Month(t, "01-2024"), To: Month(t, "01-2024"), GroupBy: domain.GroupByName}
+	got, err := repo.SumByGroup(t.Context(), f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := make([]string, 0, len(got))
+	for _, g := range got {
+		keys = append(keys, g.Key)
+	}
+	if want := "[Banana Zebra apple banana]"; fmt.Sprint(keys) != want {
+		t.Fatalf("got=%v want=%s", keys, want)
+	}
+}
@@ -1,29 +1,43 @@
 package domain
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// MonthLayout is the MM-YYYY format used for months in the API.
+const MonthLayout = "01-2006"
+
 // Service ...
 type Service struct {
+	id        int
 	name      string
 	price     int
 	uuid      uuid.UUID
 	startDate time.Time
+	endDate   *time.Time
+	createdAt time.Time
+	updatedAt time.Time
 }
 
 // ListFilterService ...
+// From and To select services whose active months overlap [From, To].
+// Cursor continues a previous page; WithTotal also counts all matching services.
 type ListFilterService struct {
-	Name          string
-	Price         int
-	Uuid          *uuid.UUID
-	FromStartDate *time.Time
-	ToStartDate   *time.Time
-	SortBy        string
-	SortDir       string
-	Limit         int
+	Name      string
+	Price     int
+	Uuid      *uuid.UUID
+	From      *time.Time
+	To        *time.Time
+	SortBy    string
+	SortDir   string
+	Limit     int
+	Cursor    *Cursor
+	WithTotal bool
 }
 
 // CreatedRequest ...
@@ -32,24 +46,146 @@ type CreatedRequest struct {
 	Price     int    `json:"price"`
 	Uuid      string `json:"user_id"`
 	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date,omitempty"`
+}
+
+// ToService validates the request and builds a Service from it.
+func (in CreatedRequest) ToService() (*Service, error) {
+	var verr ValidationError
+	if strings.TrimSpace(in.Name) == "" {
+		verr.Add("service_name", "required")
+	}
+	if in.Price < 0 {
+		verr.Add("price", "must be >= 0")
+	}
+	sdate, err := time.Parse(MonthLayout, in.StartDate)
+	if err != nil {
+		verr.Add("start_date", "want MM-YYYY")
+	}
+	var edate *time.Time
+	if in.EndDate != "" {
+		t, err := time.Parse(MonthLayout, in.EndDate)
+		switch {
+		case err != nil:
+			verr.Add("end_date", "want MM-YYYY")
+		case sdate.After(t):
+			verr.Add("end_date", "must be >= start_date")
+		default:
+			edate = &t
+		}
+	}
+	uid, err := uuid.Parse(in.Uuid)
+	if err != nil {
+		verr.Add("user_id", "invalid uuid")
+	}
+	if err := verr.Err(); err != nil {
+		return nil, err
+	}
+	s := NewService(in.Name, in.Price, uid, sdate)
+	s.SetEndDate(edate)
+	return s, nil
+}
+
+// ParseID converts a path id into a service id.
+func ParseID(sid string) (int, error) {
+	id, err := strconv.Atoi(sid)
+	if err != nil || id < 1 {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidID, sid)
+	}
+	return id, nil
+}
+
+// ServiceResponse is the API representation of a stored service.
+type ServiceResponse struct {
+	ID        int       `json:"id" example:"1"`
+	Name      string    `json:"service_name" example:"Yandex Plus"`
+	Price     int       `json:"price" example:"400"`
+	Uuid      string    `json:"user_id" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
+	StartDate string    `json:"start_date" example:"07-2025"`
+	EndDate   string    `json:"end_date,omitempty" example:"12-2025"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// NewServiceResponse ...
+func NewServiceResponse(s *Service) ServiceResponse {
+	out := ServiceResponse{
+		ID:        s.id,
+		Name:      s.name,
+		Price:     s.price,
+		Uuid:      s.uuid.String(),
+		StartDate: s.startDate.Format(MonthLayout),
+		CreatedAt: s.createdAt,
+		UpdatedAt: s.updatedAt,
+	}
+	if s.endDate != nil {
+		out.EndDate = s.endDate.Format(MonthLayout)
+	}
+	return out
 }
 
 // ListResult ...
+// Repositories set Next and Prev, the handler encodes them into the cursor tokens.
 type ListResult struct {
-	Items []CreatedRequest
+	Items      []ServiceResponse `json:"items"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+	PrevCursor string            `json:"prev_cursor,omitempty"`
+	Total      *int              `json:"total,omitempty"`
+	Next       *Cursor           `json:"-"`
+	Prev       *Cursor           `json:"-"`
 }
 
-// SumListService ...
+// Summary grouping keys.
+const (
+	GroupByName = "service_name"
+	GroupByUser = "user_id"
+)
+
+// SumFilterService ...
+// From and To select services whose active months overlap [From, To].
 type SumFilterService struct {
-	Name          string
-	Uuid          *uuid.UUID
-	FromStartDate *time.Time
-	ToStartDate   *time.Time
+	Name    string
+	Uuid    *uuid.UUID
+	From    *time.Time
+	To      *time.Time
+	GroupBy string
+}
+
+// PeriodEnd returns the last month of the summary window, the current month when To is unset.
+func (f SumFilterService) PeriodEnd(now time.Time) time.Time {
+	if f.To != nil {
+		return *f.To
+	}
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
 }
 
 // SumResult ...
 type SumResult struct {
-	Total int `json:"total"`
+	Total  int        `json:"total"`
+	Groups []GroupSum `json:"groups,omitempty"`
+}
+
+// GroupSum ...
+type GroupSum struct {
+	Key   string `json:"key" example:"Yandex Plus"`
+	Total int    `json:"total"`
+	Count int    `json:"count"`
+}
+
+// MonthSum ...
+type MonthSum struct {
+	Month string `json:"month" example:"01-2024"`
+	Total int    `json:"total"`
+	Count int    `json:"count"`
+}
+
+// MonthSpan counts the months in [from, to], zero when to is before from.
+func MonthSpan(from, to time.Time) int {
+	n := monthIndex(to) - monthIndex(from) + 1
+	if n < 0 {
+		return 0
+	}
+	return n
 }
 
 // NewService ...
@@ -62,6 +198,38 @@ func NewService(sn string, sp int, uuid uuid.UUID, sd time.Time) *Service {
 	}
 }
 
+// Clone returns a deep copy of s.
+func (s *Service) Clone() *Service {
+	c := *s
+	if s.endDate != nil {
+		ed := *s.endDate
+		c.endDate = &ed
+	}
+	return &c
+}
+
+// GetID returns the storage id, zero until the service is saved.
+func (s *Service) GetID() int {
+	return s.id
+}
+
+// GetCreatedAt ...
+func (s *Service) GetCreatedAt() time.Time {
+	return s.createdAt
+}
+
+// GetUpdatedAt ...
+func (s *Service) GetUpdatedAt() time.Time {
+	return s.updatedAt
+}
+
+// SetMeta is called by repositories once the service is stored.
+func (s *Service) SetMeta(id int, createdAt, updatedAt time.Time) {
+	s.id = id
+	s.createdAt = createdAt
+	s.updatedAt = updatedAt
+}
+
 // GetName ...
 func (s *Service) GetName() string {
 	return s.name
@@ -81,3 +249,35 @@ func (s *Service) GetUUID() uuid.UUID {
 func (s *Service) GetStartDate() time.Time {
 	return s.startDate
 }
+
+// GetEndDate returns the last active month, nil while the service is open-ended.
+func (s *Service) GetEndDate() *time.Time {
+	return s.endDate
+}
+
+// SetEndDate ...
+func (s *Service) SetEndDate(ed *time.Time) {
+	s.endDate = ed
+}
+
+// ActiveMonths counts the months within [from, to] in which the service is billed.
+// A nil from means the window starts with the service itself.
+func (s *Service) ActiveMonths(from *time.Time, to time.Time) int {
+	lo := monthIndex(s.startDate)
+	if from != nil && monthIndex(*from) > lo {
+		lo = monthIndex(*from)
+	}
+	hi := monthIndex(to)
+	if s.endDate != nil && monthIndex(*s.endDate) < hi {
+		hi = monthIndex(*s.endDate)
+	}
+	if hi < lo {
+		return 0
+	}
+	return hi - lo + 1
+}
+
+// monthIndex ...
+func monthIndex(t time.Time) int {
+	return t.Year()*12 + int(t.Month()) - 1
+}
@@ -1,11 +1,27 @@
 package domain
 
+import (
+	"context"
+	"iter"
+)
+
 // ServiceRepository ...
+// Save and GetByID fill the service meta (id, created and updated time).
 type ServiceRepository interface {
-	Save(s *Service) (int, error)
-	GetByID(id string) (*Service, error)
-	UpdateByID(sid string, s *Service) error
-	DeleteByID(sid string) error
-	ListByFilter(ListFilterService) (ListResult, error)
-	SumByFilter(SumFilterService) (SumResult, error)
+	Save(ctx context.Context, s *Service) (int, error)
+	GetByID(ctx context.Context, id string) (*Service, error)
+	UpdateByID(ctx context.Context, sid string, s *Service) error
+	DeleteByID(ctx context.Context, sid string) error
+	ListByFilter(ctx context.Context, f ListFilterService) (ListResult, error)
+	// IterateByFilter yields every service matching f in the ListByFilter order,
+	// ignoring Limit, Cursor and WithTotal. Iteration stops after the first error.
+	IterateByFilter(ctx context.Context, f ListFilterService) iter.Seq2[*Service, error]
+	SumByFilter(ctx context.Context, f SumFilterService) (SumResult, error)
+	SumByMonth(ctx context.Context, f SumFilterService) ([]MonthSum, error)
+	SumByGroup(ctx context.Context, f SumFilterService) ([]GroupSum, error)
+	// WithTx runs fn as one unit of work: every change fn makes through tx is
+	// kept when it returns nil and discarded otherwise. A service read through
+	// tx.GetByID cannot change under fn until it returns. tx must not be used
+	// after fn returns.
+	WithTx(ctx context.Context, fn func(tx ServiceRepository) error) error
 }
@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func month(t *testing.T, s string) time.Time {
+	t.Helper()
+	m, err := time.Parse(MonthLayout, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestActiveMonths(t *testing.T) {
+	cases := []struct {
+		name  string
+		start string
+		end   string
+		from  string
+		to    string
+		want  int
+	}{
+		{"started_before_window", "01-2024", "", "03-2024", "05-2024", 3},
+		{"started_inside_window", "04-2024", "", "03-2024", "05-2024", 2},
+		{"ended_inside_window", "01-2024", "03-2024", "02-2024", "12-2024", 2},
+		{"after_window", "07-2024", "", "03-2024", "05-2024", 0},
+		{"ended_before_window", "01-2023", "12-2023", "01-2024", "05-2024", 0},
+		{"no_from", "11-2023", "", "", "02-2024", 4},
+		{"single_month", "02-2024", "02-2024", "", "12-2024", 1},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			s := NewService("Yandex Plus", 400, uuid.New(), month(t, c.start))
+			if c.end != "" {
+				ed := month(t, c.end)
+				s.SetEndDate(&ed)
+			}
+			var from *time.Time
+			if c.from != "" {
+				f := month(t, c.from)
+				from = &f
+			}
+			if got := s.ActiveMonths(from, month(t, c.to)); got != c.want {
+				t.Fatalf("ActiveMonths: got=%d want=%d", got, c.want)
+			}
+		})
+	}
+}
+
+func TestPeriodEnd(t *testing.T) {
+	now := time.Date(2025, time.August, 17, 10, 0, 0, 0, time.UTC)
+	if got := (SumFilterService{}).PeriodEnd(now); got.Format(MonthLayout) != "08-2025" {
+		t.Fatalf("PeriodEnd default: got=%s", got)
+	}
+	to := month(t, "03-2024")
+	if got := (SumFilterService{To: &to}).PeriodEnd(now); !got.Equal(to) {
+		t.Fatalf("PeriodEnd: got=%s want=%s", got, to)
+	}
+}
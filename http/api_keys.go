@@ -0,0 +1,115 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/animans/REST-API-test-task/auth"
+	"github.com/animans/REST-API-test-task/domain"
+	"github.com/animans/REST-API-test-task/logging"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// CreateAPIKey
+// @Summary      Issue API key
+// @Description  Выпустить API ключ; секрет возвращается только в этом ответе
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        input body     domain.CreateAPIKeyRequest true "api key payload"
+// @Success      201   {object} domain.APIKeyCreated
+// @Header       201   {string} Location "/admin/api-keys/{id}"
+// @Failure      400   {object} Problem
+// @Failure      401   {object} Problem
+// @Failure      403   {object} Problem
+// @Failure      422   {object} Problem
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Router       /admin/api-keys [post]
+func (h *Handlers) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	log := logging.FromContext(r.Context())
+	log.Info("CreateAPIKey start")
+	var in domain.CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		log.Error("invalid json", "err", err)
+		writeProblem(w, r, http.StatusBadRequest, "invalid json")
+		return
+	}
+	k, err := in.ToAPIKey(time.Now())
+	if err != nil {
+		log.Error("invalid request", "err", err)
+		writeError(w, r, err)
+		return
+	}
+	secret, prefix, hash, err := auth.GenerateAPIKey()
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	k.Prefix, k.Hash = prefix, hash
+	if err := h.APIKeys.Create(r.Context(), k); err != nil {
+		log.Error("create api key error", "err", err)
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Location", "/admin/api-keys/"+k.ID.String())
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(domain.APIKeyCreated{APIKey: *k, Key: secret})
+	log.Info("CreateAPIKey done", "key_id", k.ID, "scopes", k.Scopes)
+}
+
+// ListAPIKeys
+// @Summary      List API keys
+// @Tags         admin
+// @Produce      json
+// @Success      200 {object} domain.APIKeyList
+// @Failure      401 {object} Problem
+// @Failure      403 {object} Problem
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Router       /admin/api-keys [get]
+func (h *Handlers) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	log := logging.FromContext(r.Context())
+	keys, err := h.APIKeys.List(r.Context())
+	if err != nil {
+		log.Error("list api keys error", "err", err)
+		writeError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(domain.APIKeyList{Items: keys})
+}
+
+// RevokeAPIKey
+// @Summary      Revoke API key
+// @Tags         admin
+// @Param        id path string true "API key ID" format(uuid)
+// @Success      204 {string} string "revoked"
+// @Failure      400 {object} Problem
+// @Failure      401 {object} Problem
+// @Failure      403 {object} Problem
+// @Failure      404 {object} Problem
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Router       /admin/api-keys/{id} [delete]
+func (h *Handlers) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	log := logging.FromContext(r.Context())
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid api key id")
+		return
+	}
+	if err := h.APIKeys.Revoke(r.Context(), id); err != nil {
+		log.Error("revoke api key error", "err", err)
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+	log.Info("RevokeAPIKey done", "key_id", id)
+}
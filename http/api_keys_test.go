@@ -0,0 +1,86 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/animans/REST-API-test-task/auth"
+	"github.com/animans/REST-API-test-task/domain"
+	"github.com/animans/REST-API-test-task/infastructure"
+	"github.com/google/uuid"
+)
+
+func TestAPIKeys(t *testing.T) {
+	keys := infastructure.NewAPIKeyRepoMem()
+	h := NewHandlers(infastructure.NewServiceRepoMem(), "")
+	h.APIKeys = keys
+	h.Auth = auth.Chain{
+		tokenAuth{"admin": {Admin: true, Scopes: domain.Scopes, Method: "test"}, "user": {UserID: uuid.New(), Scopes: []string{domain.ScopeRead, domain.ScopeWrite}}},
+		auth.NewAPIKeyAuthenticator(keys),
+	}
+	router := h.Router()
+
+	do := func(method, target string, body any, header, value string) *httptest.ResponseRecorder {
+		t.Helper()
+		var req *http.Request
+		if body != nil {
+			req = httptest.NewRequest(method, target, mustJSON(t, body))
+		} else {
+			req = httptest.NewRequest(method, target, nil)
+		}
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	asAdmin := func(method, target string, body any) *httptest.ResponseRecorder {
+		return do(method, target, body, "Authorization", "Bearer admin")
+	}
+
+	owner := uuid.New()
+	rec := asAdmin(http.MethodPost, "/admin/api-keys", domain.CreateAPIKeyRequest{Name: "reader", UserID: owner.String(), Scopes: []string{"read"}})
+	wantStatus(t, rec, http.StatusCreated)
+	var created domain.APIKeyCreated
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil || created.Key == "" || created.Prefix == "" {
+		t.Fatalf("created: %+v, %v", created, err)
+	}
+	if rec.Header().Get("Cache-Control") != "no-store" {
+		t.Fatal("issued key must not be cached")
+	}
+	wantStatus(t, asAdmin(http.MethodPost, "/admin/api-keys", domain.CreateAPIKeyRequest{Name: "bad", Scopes: []string{"root"}}), http.StatusUnprocessableEntity)
+	// A key without a user must carry the admin scope, and only that makes it admin.
+	rec = asAdmin(http.MethodPost, "/admin/api-keys", domain.CreateAPIKeyRequest{Name: "sync", Scopes: []string{"read", "write"}})
+	wantStatus(t, rec, http.StatusUnprocessableEntity)
+	wantBodyContains(t, rec, "user_id")
+	rec = asAdmin(http.MethodPost, "/admin/api-keys", domain.CreateAPIKeyRequest{Name: "ops", Scopes: []string{"read", "admin"}})
+	wantStatus(t, rec, http.StatusCreated)
+	var ops domain.APIKeyCreated
+	if err := json.Unmarshal(rec.Body.Bytes(), &ops); err != nil || ops.UserID != nil {
+		t.Fatalf("ops key: %+v, %v", ops, err)
+	}
+	wantStatus(t, do(http.MethodGet, "/service?user_id="+uuid.NewString(), nil, auth.APIKeyHeader, ops.Key), http.StatusOK)
+	wantStatus(t, do(http.MethodGet, "/admin/api-keys", nil, "Authorization", "Bearer user"), http.StatusForbidden)
+	wantStatus(t, do(http.MethodGet, "/admin/api-keys", nil, "", ""), http.StatusUnauthorized)
+
+	rec = do(http.MethodGet, "/service", nil, auth.APIKeyHeader, created.Key)
+	wantStatus(t, rec, http.StatusOK)
+	wantStatus(t, do(http.MethodGet, "/service?user_id="+uuid.NewString(), nil, auth.APIKeyHeader, created.Key), http.StatusForbidden)
+	wantStatus(t, do(http.MethodPost, "/service", domain.CreatedRequest{Name: "X", Price: 1, Uuid: owner.String(), StartDate: "01-2025"}, auth.APIKeyHeader, created.Key), http.StatusForbidden)
+	wantStatus(t, do(http.MethodGet, "/admin/api-keys", nil, auth.APIKeyHeader, created.Key), http.StatusForbidden)
+
+	rec = asAdmin(http.MethodGet, "/admin/api-keys", nil)
+	wantStatus(t, rec, http.StatusOK)
+	if body := rec.Body.String(); !json.Valid(rec.Body.Bytes()) || strings.Contains(body, created.Key) || !strings.Contains(body, created.ID.String()) {
+		t.Fatalf("list: %s", body)
+	}
+
+	wantStatus(t, asAdmin(http.MethodDelete, "/admin/api-keys/"+created.ID.String(), nil), http.StatusNoContent)
+	wantStatus(t, asAdmin(http.MethodDelete, "/admin/api-keys/"+uuid.NewString(), nil), http.StatusNotFound)
+	wantStatus(t, asAdmin(http.MethodDelete, "/admin/api-keys/nope", nil), http.StatusBadRequest)
+	wantStatus(t, do(http.MethodGet, "/service", nil, auth.APIKeyHeader, created.Key), http.StatusUnauthorized)
+}
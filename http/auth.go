@@ -0,0 +1,117 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/animans/REST-API-test-task/auth"
+	"github.com/animans/REST-API-test-task/domain"
+	"github.com/animans/REST-API-test-task/logging"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// Authenticate rejects requests without valid credentials and stores the caller in the context.
+func Authenticate(a auth.Authenticator) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p, err := a.Authenticate(r)
+			if err != nil {
+				log := logging.FromContext(r.Context())
+				log.Warn("authentication failed", "err", err)
+				challenge := `Bearer realm="api"`
+				if errors.Is(err, auth.ErrInvalidCredentials) {
+					challenge += `, error="invalid_token"`
+				}
+				w.Header().Set("WWW-Authenticate", challenge)
+				writeError(w, r, err)
+				return
+			}
+			ctx := auth.WithPrincipal(r.Context(), p)
+			log := logging.FromContext(ctx).With("auth", p.Method, "user_id", p.UserID.String(), "admin", p.Admin)
+			ctx = logging.WithLogger(ctx, log)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// RequireScope rejects authenticated callers without scope.
+// Requests without a principal pass, they only reach here when auth is disabled.
+func RequireScope(scope string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := checkScope(r.Context(), scope); err != nil {
+				writeError(w, r, err)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// methodScope requires the read scope for safe methods and write for the rest.
+func methodScope(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scope := domain.ScopeWrite
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			scope = domain.ScopeRead
+		}
+		if err := checkScope(r.Context(), scope); err != nil {
+			writeError(w, r, err)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// checkScope ...
+func checkScope(ctx context.Context, scope string) error {
+	if p, ok := auth.FromContext(ctx); ok && !p.HasScope(scope) {
+		return fmt.Errorf("%w: %s scope required", domain.ErrForbidden, scope)
+	}
+	return nil
+}
+
+// scopeUser restricts a user_id filter to the caller's own user.
+func scopeUser(ctx context.Context, requested *uuid.UUID) (*uuid.UUID, error) {
+	own := auth.Scope(ctx)
+	if own == nil {
+		return requested, nil
+	}
+	if requested != nil && *requested != *own {
+		return nil, fmt.Errorf("%w: user_id must be the authenticated user", domain.ErrForbidden)
+	}
+	return own, nil
+}
+
+// checkOwner fails with ErrForbidden when a scoped caller writes a service of another user.
+func checkOwner(ctx context.Context, s *domain.Service) error {
+	if own := auth.Scope(ctx); own != nil && s.GetUUID() != *own {
+		return fmt.Errorf("%w: user_id must be the authenticated user", domain.ErrForbidden)
+	}
+	return nil
+}
+
+// visible reports whether the caller may see s; other users' services are reported as not found.
+func visible(ctx context.Context, s *domain.Service) bool {
+	own := auth.Scope(ctx)
+	return own == nil || s.GetUUID() == *own
+}
+
+// authorizeIn loads the service id from repo for scoped callers and hides other
+// users' services. Run it in the transaction of the write it guards.
+func authorizeIn(ctx context.Context, repo domain.ServiceRepository, id string) error {
+	if auth.Scope(ctx) == nil {
+		return nil
+	}
+	s, err := repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !visible(ctx, s) {
+		return fmt.Errorf("%w: id=%s", domain.ErrNotFound, id)
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/animans/REST-API-test-task/auth"
+	"github.com/animans/REST-API-test-task/domain"
+	"github.com/animans/REST-API-test-task/infastructure"
+	"github.com/google/uuid"
+)
+
+// tokenAuth maps bearer tokens to principals.
+type tokenAuth map[string]auth.Principal
+
+func (a tokenAuth) Authenticate(r *http.Request) (auth.Principal, error) {
+	tok, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return auth.Principal{}, auth.ErrNoCredentials
+	}
+	p, ok := a[tok]
+	if !ok {
+		return auth.Principal{}, auth.ErrInvalidCredentials
+	}
+	return p, nil
+}
+
+func TestAuthScoping(t *testing.T) {
+	alice, bob := uuid.New(), uuid.New()
+	h := NewHandlers(infastructure.NewServiceRepoMem(), "")
+	h.Auth = tokenAuth{
+		"alice": {UserID: alice, Scopes: []string{domain.ScopeRead, domain.ScopeWrite}, Method: "test"},
+		"bob":   {UserID: bob, Scopes: []string{domain.ScopeRead, domain.ScopeWrite}, Method: "test"},
+		"admin": {Admin: true, Scopes: domain.Scopes, Method: "test"},
+	}
+	router := h.Router()
+
+	do := func(token, method, target string, body any) *httptest.ResponseRecorder {
+		t.Helper()
+		var req *http.Request
+		if body != nil {
+			req = httptest.NewRequest(method, target, mustJSON(t, body))
+		} else {
+			req = httptest.NewRequest(method, target, nil)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	create := func(token string, user uuid.UUID) int {
+		t.Helper()
+		rec := do(token, http.MethodPost, "/service", domain.CreatedRequest{Name: "Netflix", Price: 100, Uuid: user.String(), StartDate: "01-2025"})
+		wantStatus(t, rec, http.StatusCreated)
+		var got domain.ServiceResponse
+		_ = json.Unmarshal(rec.Body.Bytes(), &got)
+		return got.ID
+	}
+	aliceID := create("alice", alice)
+	create("bob", bob)
+
+	rec := do("", http.MethodGet, "/service", nil)
+	wantStatus(t, rec, http.StatusUnauthorized)
+	if rec.Header().Get("WWW-Authenticate") == "" {
+		t.Fatal("missing WWW-Authenticate")
+	}
+	rec = do("stolen", http.MethodGet, "/service", nil)
+	wantStatus(t, rec, http.StatusUnauthorized)
+	wantBodyContains(t, rec, "/problems/unauthorized")
+	if !strings.Contains(rec.Header().Get("WWW-Authenticate"), "invalid_token") {
+		t.Fatalf("WWW-Authenticate: %q", rec.Header().Get("WWW-Authenticate"))
+	}
+	wantStatus(t, do("", http.MethodGet, "/healthz", nil), http.StatusOK)
+
+	list := func(token, query string) []domain.ServiceResponse {
+		t.Helper()
+		rec := do(token, http.MethodGet, "/service"+query, nil)
+		wantStatus(t, rec, http.StatusOK)
+		var got domain.ListResult
+		_ = json.Unmarshal(rec.Body.Bytes(), &got)
+		return got.Items
+	}
+	if got := list("alice", ""); len(got) != 1 || got[0].Uuid != alice.String() {
+		t.Fatalf("alice list: %+v", got)
+	}
+	if got := list("admin", ""); len(got) != 2 {
+		t.Fatalf("admin list: %+v", got)
+	}
+	if got := list("admin", "?user_id="+bob.String()); len(got) != 1 {
+		t.Fatalf("admin filtered list: %+v", got)
+	}
+
+	wantStatus(t, do("alice", http.MethodGet, "/service?user_id="+bob.String(), nil), http.StatusForbidden)
+	wantStatus(t, do("alice", http.MethodGet, "/service/summary?user_id="+bob.String(), nil), http.StatusForbidden)
+	wantStatus(t, do("alice", http.MethodPost, "/service", domain.CreatedRequest{Name: "X", Price: 1, Uuid: bob.String(), StartDate: "01-2025"}), http.StatusForbidden)
+
+	target := fmt.Sprintf("/service/%d", aliceID)
+	wantStatus(t, do("bob", http.MethodGet, target, nil), http.StatusNotFound)
+	wantStatus(t, do("bob", http.MethodPut, target, domain.CreatedRequest{Name: "X", Price: 1, Uuid: bob.String(), StartDate: "01-2025"}), http.StatusNotFound)
+	wantStatus(t, do("bob", http.MethodDelete, target, nil), http.StatusNotFound)
+	wantStatus(t, do("alice", http.MethodPut, target, domain.CreatedRequest{Name: "X", Price: 1, Uuid: bob.String(), StartDate: "01-2025"}), http.StatusForbidden)
+	wantStatus(t, do("alice", http.MethodGet, target, nil), http.StatusOK)
+	wantStatus(t, do("admin", http.MethodDelete, target, nil), http.StatusNoContent)
+}
+
+func TestOwnerCheckInTx(t *testing.T) {
+	alice := uuid.New()
+	repo := infastructure.NewServiceRepoMem()
+	id, err := repo.Save(t.Context(), domain.NewService("Netflix", 100, alice, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := NewHandlers(txOnlyRepo{repo, t}, "")
+	h.Auth = tokenAuth{"alice": {UserID: alice, Scopes: []string{domain.ScopeRead, domain.ScopeWrite}, Method: "test"}}
+	router := h.Router()
+	do := func(method string, body any) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, "/service/"+strconv.Itoa(id), nil)
+		if body != nil {
+			req = httptest.NewRequest(method, "/service/"+strconv.Itoa(id), mustJSON(t, body))
+		}
+		req.Header.Set("Authorization", "Bearer alice")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	wantStatus(t, do(http.MethodPut, domain.CreatedRequest{Name: "Okko", Price: 200, Uuid: alice.String(), StartDate: "02-2025"}), http.StatusNoContent)
+	wantStatus(t, do(http.MethodDelete, nil), http.StatusNoContent)
+	wantStatus(t, do(http.MethodDelete, nil), http.StatusNotFound)
+}
+
+// txOnlyRepo fails the test when a single service is read or written outside WithTx.
+type txOnlyRepo struct {
+	domain.ServiceRepository
+	t *testing.T
+}
+
+func (r txOnlyRepo) GetByID(ctx context.Context, id string) (*domain.Service, error) {
+	r.t.Error("GetByID outside WithTx")
+	return r.ServiceRepository.GetByID(ctx, id)
+}
+
+func (r txOnlyRepo) UpdateByID(ctx context.Context, id string, s *domain.Service) error {
+	r.t.Error("UpdateByID outside WithTx")
+	return r.ServiceRepository.UpdateByID(ctx, id, s)
+}
+
+func (r txOnlyRepo) DeleteByID(ctx context.Context, id string) error {
+	r.t.Error("DeleteByID outside WithTx")
+	return r.ServiceRepository.DeleteByID(ctx, id)
+}
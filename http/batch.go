@@ -0,0 +1,220 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/animans/REST-API-test-task/domain"
+	"github.com/animans/REST-API-test-task/logging"
+)
+
+// BatchResult is the outcome of one operation, Status as if it were sent alone.
+// In a failed atomic batch the other operations report 424 Failed Dependency.
+type BatchResult struct {
+	Index  int                 `json:"index"`
+	Op     string              `json:"op" example:"create"`
+	Status int                 `json:"status" example:"201"`
+	ID     int                 `json:"id,omitempty" example:"42"`
+	Error  string              `json:"error,omitempty"`
+	Fields []domain.FieldError `json:"errors,omitempty"`
+}
+
+// BatchResponse ...
+type BatchResponse struct {
+	Atomic    bool          `json:"atomic"`
+	Succeeded int           `json:"succeeded"`
+	Failed    int           `json:"failed"`
+	Results   []BatchResult `json:"results"`
+}
+
+// errBatchAborted rolls back an atomic batch after a failed operation.
+var errBatchAborted = errors.New("batch aborted")
+
+// Batch
+// @Summary      Batch create, update and delete
+// @Description  Выполнить до 1000 операций по порядку. При atomic=true применяются все или ни одна (откат при первой ошибке),
+// @Description  иначе каждая операция выполняется отдельно. Статус каждой операции — как у одиночного запроса; ответ всегда 200.
+// @Tags         service
+// @Accept       json
+// @Produce      json
+// @Param        input body     domain.BatchRequest true "operations"
+// @Success      200   {object} BatchResponse
+// @Failure      400   {object} Problem
+// @Failure      401   {object} Problem
+// @Failure      403   {object} Problem
+// @Failure      413   {object} Problem
+// @Failure      422   {object} Problem
+// @Failure      429   {object} Problem
+// @Failure      500   {object} Problem
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Router       /service/batch [post]
+func (h *Handlers) Batch(w http.ResponseWriter, r *http.Request) {
+	log := logging.FromContext(r.Context())
+	log.Info("Batch start")
+	var in domain.BatchRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, h.maxImportBytes())).Decode(&in); err != nil {
+		log.Error("invalid json", "err", err)
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeProblem(w, r, http.StatusRequestEntityTooLarge, "request body exceeds "+strconv.FormatInt(tooLarge.Limit, 10)+" bytes")
+			return
+		}
+		writeProblem(w, r, http.StatusBadRequest, "invalid json")
+		return
+	}
+	if err := in.Validate(); err != nil {
+		log.Error("invalid request", "err", err)
+		writeError(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+	out := BatchResponse{Atomic: in.Atomic, Results: make([]BatchResult, len(in.Operations))}
+	if in.Atomic {
+		failed := -1
+		err := h.Repo.WithTx(ctx, func(tx domain.ServiceRepository) error {
+			for i, op := range in.Operations {
+				out.Results[i] = runOperation(ctx, tx, i, op)
+				if out.Results[i].Error != "" {
+					failed = i
+					return errBatchAborted
+				}
+			}
+			return nil
+		})
+		if failed < 0 && err != nil {
+			log.Error("batch commit error", "err", err)
+			writeError(w, r, err)
+			return
+		}
+		if failed >= 0 {
+			for i, op := range in.Operations {
+				if i == failed {
+					continue
+				}
+				out.Results[i] = BatchResult{Index: i, Op: op.Op, Status: http.StatusFailedDependency, ID: op.ID,
+					Error: "operation " + strconv.Itoa(failed) + " failed, batch rolled back"}
+			}
+		}
+	} else {
+		for i, op := range in.Operations {
+			out.Results[i] = runOperation(ctx, h.Repo, i, op)
+		}
+	}
+	for _, res := range out.Results {
+		if res.Error != "" {
+			out.Failed++
+		} else {
+			out.Succeeded++
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(out)
+	log.Info("Batch done", "atomic", out.Atomic, "succeeded", out.Succeeded, "failed", out.Failed)
+}
+
+// operationStatus is the success status of each operation kind.
+var operationStatus = map[string]int{
+	domain.OpCreate: http.StatusCreated,
+	domain.OpUpdate: http.StatusNoContent,
+	domain.OpDelete: http.StatusNoContent,
+}
+
+// runOperation applies op and reports it as the single-item handler would.
+func runOperation(ctx context.Context, repo domain.ServiceRepository, i int, op domain.BatchOperation) BatchResult {
+	id, err := applyOperation(ctx, repo, op)
+	res := BatchResult{Index: i, Op: op.Op, Status: operationStatus[op.Op], ID: id}
+	if err == nil {
+		return res
+	}
+	res.Status = errorStatus(err)
+	res.Error = err.Error()
+	var verr *domain.ValidationError
+	if errors.As(err, &verr) {
+		res.Error, res.Fields = domain.ErrValidation.Error(), verr.Fields
+	}
+	if res.Status == http.StatusInternalServerError {
+		logging.FromContext(ctx).Error("batch operation error", "index", i, "err", err)
+		res.Error = http.StatusText(res.Status)
+	}
+	return res
+}
+
+// applyOperation runs op through repo with the checks of Create, Put and Delete.
+func applyOperation(ctx context.Context, repo domain.ServiceRepository, op domain.BatchOperation) (int, error) {
+	switch op.Op {
+	case domain.OpCreate:
+		ser, err := operationService(op)
+		if err != nil {
+			return 0, err
+		}
+		if err := checkOwner(ctx, ser); err != nil {
+			return 0, err
+		}
+		return repo.Save(ctx, ser)
+	case domain.OpUpdate:
+		ser, err := operationService(op)
+		if err != nil {
+			return op.ID, err
+		}
+		id := strconv.Itoa(op.ID)
+		if err := authorizeIn(ctx, repo, id); err != nil {
+			return op.ID, err
+		}
+		if err := checkOwner(ctx, ser); err != nil {
+			return op.ID, err
+		}
+		return op.ID, repo.UpdateByID(ctx, id, ser)
+	case domain.OpDelete:
+		if err := operationID(op); err != nil {
+			return op.ID, err
+		}
+		id := strconv.Itoa(op.ID)
+		if err := authorizeIn(ctx, repo, id); err != nil {
+			return op.ID, err
+		}
+		return op.ID, repo.DeleteByID(ctx, id)
+	}
+	var verr domain.ValidationError
+	verr.Add("op", "must be create, update or delete")
+	return op.ID, verr.Err()
+}
+
+// operationID requires the id of update and delete.
+func operationID(op domain.BatchOperation) error {
+	if op.ID < 1 {
+		var verr domain.ValidationError
+		verr.Add("id", "required")
+		return verr.Err()
+	}
+	return nil
+}
+
+// operationService validates the id and data of create and update.
+func operationService(op domain.BatchOperation) (*domain.Service, error) {
+	var verr domain.ValidationError
+	if op.Op == domain.OpUpdate && op.ID < 1 {
+		verr.Add("id", "required")
+	}
+	if op.Data == nil {
+		verr.Add("data", "required")
+		return nil, verr.Err()
+	}
+	ser, err := op.Data.ToService()
+	var more *domain.ValidationError
+	if errors.As(err, &more) {
+		verr.Fields = append(verr.Fields, more.Fields...)
+	} else if err != nil {
+		return nil, err
+	}
+	if err := verr.Err(); err != nil {
+		return nil, err
+	}
+	return ser, nil
+}
@@ -0,0 +1,105 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/animans/REST-API-test-task/domain"
+	"github.com/animans/REST-API-test-task/infastructure"
+	"github.com/google/uuid"
+)
+
+func TestBatch(t *testing.T) {
+	repo := infastructure.NewServiceRepoMem()
+	h := NewHandlers(repo, "")
+	alice := uuid.New()
+	h.Auth = tokenAuth{"alice": {UserID: alice, Scopes: []string{domain.ScopeRead, domain.ScopeWrite}, Method: "test"}}
+	router := h.Router()
+	do := func(body any) (*httptest.ResponseRecorder, BatchResponse) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/service/batch", mustJSON(t, body))
+		req.Header.Set("Authorization", "Bearer alice")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		var out BatchResponse
+		if rec.Code == http.StatusOK {
+			if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return rec, out
+	}
+	statuses := func(out BatchResponse) []int {
+		s := make([]int, len(out.Results))
+		for i, r := range out.Results {
+			s[i] = r.Status
+		}
+		return s
+	}
+	data := func(name string, user uuid.UUID) *domain.CreatedRequest {
+		return &domain.CreatedRequest{Name: name, Price: 100, Uuid: user.String(), StartDate: "01-2025"}
+	}
+	count := func() int {
+		t.Helper()
+		res, err := repo.ListByFilter(t.Context(), domain.ListFilterService{Limit: 100, WithTotal: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return *res.Total
+	}
+
+	other, err := repo.Save(t.Context(), domain.NewService("Other", 1, uuid.New(), time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec, out := do(domain.BatchRequest{Atomic: true, Operations: []domain.BatchOperation{
+		{Op: domain.OpCreate, Data: data("Netflix", alice)},
+		{Op: domain.OpCreate, Data: data("Okko", alice)},
+	}})
+	wantStatus(t, rec, http.StatusOK)
+	if out.Succeeded != 2 || out.Failed != 0 || out.Results[0].ID == 0 || out.Results[1].ID == 0 {
+		t.Fatalf("atomic create: %+v", out)
+	}
+	netflix, okko := out.Results[0].ID, out.Results[1].ID
+
+	// The delete succeeds before the invalid update, the rollback must restore it.
+	rec, out = do(domain.BatchRequest{Atomic: true, Operations: []domain.BatchOperation{
+		{Op: domain.OpDelete, ID: netflix},
+		{Op: domain.OpCreate, Data: data("Kion", alice)},
+		{Op: domain.OpUpdate, ID: okko, Data: &domain.CreatedRequest{Name: "Okko", Price: -1, Uuid: alice.String(), StartDate: "01-2025"}},
+		{Op: domain.OpDelete, ID: okko},
+	}})
+	wantStatus(t, rec, http.StatusOK)
+	if got := statuses(out); out.Failed != 4 || got[0] != 424 || got[1] != 424 || got[2] != 422 || got[3] != 424 || len(out.Results[2].Fields) != 1 {
+		t.Fatalf("atomic rollback: %v %+v", got, out)
+	}
+	if n := count(); n != 3 {
+		t.Fatalf("atomic rollback left %d services, want 3", n)
+	}
+
+	rec, out = do(domain.BatchRequest{Operations: []domain.BatchOperation{
+		{Op: domain.OpDelete, ID: netflix},
+		{Op: domain.OpUpdate, ID: okko, Data: data("Okko HD", alice)},
+		{Op: domain.OpCreate, Data: data("Kion", uuid.New())},
+		{Op: domain.OpDelete, ID: other},
+		{Op: domain.OpDelete, ID: 999},
+		{Op: domain.OpUpdate, Data: data("Okko", alice)},
+		{Op: "upsert"},
+	}})
+	wantStatus(t, rec, http.StatusOK)
+	if got := statuses(out); out.Succeeded != 2 || out.Failed != 5 || got[0] != 204 || got[1] != 204 || got[2] != 403 || got[3] != 404 || got[4] != 404 || got[5] != 422 || got[6] != 422 {
+		t.Fatalf("best effort: %v %+v", got, out)
+	}
+	if n := count(); n != 2 {
+		t.Fatalf("best effort left %d services, want 2", n)
+	}
+
+	rec, _ = do(domain.BatchRequest{})
+	wantStatus(t, rec, http.StatusUnprocessableEntity)
+	rec, _ = do("nope")
+	wantStatus(t, rec, http.StatusBadRequest)
+}
@@ -0,0 +1,113 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/animans/REST-API-test-task/auth"
+	"github.com/animans/REST-API-test-task/domain"
+	"github.com/animans/REST-API-test-task/logging"
+)
+
+// ProblemContentType ...
+const ProblemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 error body.
+type Problem struct {
+	Type     string              `json:"type" example:"/problems/validation-error"`
+	Title    string              `json:"title" example:"Unprocessable Entity"`
+	Status   int                 `json:"status" example:"422"`
+	Detail   string              `json:"detail,omitempty" example:"validation failed"`
+	Instance string              `json:"instance,omitempty" example:"/service"`
+	Errors   []domain.FieldError `json:"errors,omitempty"`
+}
+
+// problemTypes ...
+var problemTypes = map[int]string{
+	http.StatusBadRequest:            "/problems/bad-request",
+	http.StatusUnauthorized:          "/problems/unauthorized",
+	http.StatusForbidden:             "/problems/forbidden",
+	http.StatusNotFound:              "/problems/not-found",
+	http.StatusConflict:              "/problems/conflict",
+	http.StatusRequestEntityTooLarge: "/problems/too-large",
+	http.StatusUnsupportedMediaType:  "/problems/unsupported-media-type",
+	http.StatusUnprocessableEntity:   "/problems/validation-error",
+	http.StatusTooManyRequests:       "/problems/rate-limited",
+	http.StatusInternalServerError:   "/problems/internal-error",
+	http.StatusGatewayTimeout:        "/problems/timeout",
+	StatusClientClosedRequest:        "/problems/client-closed-request",
+}
+
+// StatusClientClosedRequest is reported when the client goes away before the response.
+const StatusClientClosedRequest = 499
+
+// errorStatus maps domain errors to HTTP status codes.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrNotFound), errors.Is(err, domain.ErrAPIKeyNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrInvalidID):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, domain.ErrValidation):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, auth.ErrNoCredentials), errors.Is(err, auth.ErrInvalidCredentials):
+		return http.StatusUnauthorized
+	case errors.Is(err, domain.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, context.Canceled):
+		return StatusClientClosedRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// writeProblem ...
+func writeProblem(w http.ResponseWriter, r *http.Request, status int, detail string, fields ...domain.FieldError) {
+	typ, ok := problemTypes[status]
+	if !ok {
+		typ = "about:blank"
+	}
+	title := http.StatusText(status)
+	if status == StatusClientClosedRequest {
+		title = "Client Closed Request"
+	}
+	p := Problem{
+		Type:     typ,
+		Title:    title,
+		Status:   status,
+		Detail:   detail,
+		Instance: r.URL.RequestURI(),
+		Errors:   fields,
+	}
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(p)
+}
+
+// writeError ...
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	status := errorStatus(err)
+	detail := err.Error()
+	if status == http.StatusInternalServerError {
+		logging.FromContext(r.Context()).Error("internal error", "err", err)
+		detail = ""
+	}
+	var verr *domain.ValidationError
+	if errors.As(err, &verr) {
+		writeProblem(w, r, status, domain.ErrValidation.Error(), verr.Fields...)
+		return
+	}
+	writeProblem(w, r, status, detail)
+}
+
+// writeBadRequest ...
+func writeBadRequest(w http.ResponseWriter, r *http.Request, verr *domain.ValidationError) {
+	writeProblem(w, r, http.StatusBadRequest, "invalid request", verr.Fields...)
+}
@@ -0,0 +1,121 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/animans/REST-API-test-task/domain"
+	"github.com/animans/REST-API-test-task/logging"
+)
+
+// DefaultReadyTimeout ...
+const DefaultReadyTimeout = 2 * time.Second
+
+// Health statuses.
+const (
+	HealthOK   = "ok"
+	HealthFail = "fail"
+)
+
+// CheckResult is the outcome of one readiness check.
+type CheckResult struct {
+	Name      string  `json:"name" example:"database"`
+	Status    string  `json:"status" example:"ok"`
+	LatencyMS float64 `json:"latency_ms" example:"1.25"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// HealthReport ...
+type HealthReport struct {
+	Status string        `json:"status" example:"ok"`
+	Checks []CheckResult `json:"checks,omitempty"`
+}
+
+// check ...
+type check struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+// readinessChecks returns the checks supported by the repository.
+func (h *Handlers) readinessChecks() []check {
+	var checks []check
+	if p, ok := h.Repo.(domain.Pinger); ok {
+		checks = append(checks, check{name: "database", run: p.Ping})
+	}
+	if v, ok := h.Repo.(domain.SchemaVersioner); ok && h.SchemaVersion > 0 {
+		checks = append(checks, check{name: "migrations", run: func(ctx context.Context) error {
+			version, dirty, err := v.SchemaVersion(ctx)
+			switch {
+			case err != nil:
+				return err
+			case dirty:
+				return fmt.Errorf("schema version %d is dirty", version)
+			case version < h.SchemaVersion:
+				return fmt.Errorf("schema version %d, want %d", version, h.SchemaVersion)
+			}
+			return nil
+		}})
+	}
+	return checks
+}
+
+// Healthz
+// @Summary      Liveness probe
+// @Tags         health
+// @Produce      json
+// @Success      200  {object} HealthReport
+// @Router       /healthz [get]
+func (h *Handlers) Healthz(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, http.StatusOK, HealthReport{Status: HealthOK})
+}
+
+// Readyz
+// @Summary      Readiness probe
+// @Description  Пингует базу и проверяет версию миграций
+// @Tags         health
+// @Produce      json
+// @Success      200  {object} HealthReport
+// @Failure      503  {object} HealthReport
+// @Router       /readyz [get]
+func (h *Handlers) Readyz(w http.ResponseWriter, r *http.Request) {
+	log := logging.FromContext(r.Context())
+	timeout := h.ReadyTimeout
+	if timeout <= 0 {
+		timeout = DefaultReadyTimeout
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+
+	report := HealthReport{Status: HealthOK, Checks: []CheckResult{}}
+	status := http.StatusOK
+	for _, c := range h.readinessChecks() {
+		start := time.Now()
+		err := c.run(ctx)
+		res := CheckResult{
+			Name:      c.name,
+			Status:    HealthOK,
+			LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+		}
+		if err != nil {
+			log.Warn("Readyz check failed", "check", c.name, "err", err)
+			res.Status = HealthFail
+			res.Error = err.Error()
+			report.Status = HealthFail
+			status = http.StatusServiceUnavailable
+		}
+		report.Checks = append(report.Checks, res)
+	}
+	writeHealth(w, status, report)
+}
+
+// writeHealth ...
+func writeHealth(w http.ResponseWriter, status int, report HealthReport) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(report)
+}
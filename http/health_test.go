@@ -0,0 +1,89 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/animans/REST-API-test-task/infastructure"
+)
+
+// healthRepo is a fakeRepo with configurable health hooks.
+type healthRepo struct {
+	fakeRepo
+	pingErr error
+	version int64
+	dirty   bool
+}
+
+func (r *healthRepo) Ping(ctx context.Context) error { return r.pingErr }
+
+func (r *healthRepo) SchemaVersion(ctx context.Context) (int64, bool, error) {
+	return r.version, r.dirty, nil
+}
+
+func readyz(t *testing.T, h *Handlers) (int, HealthReport) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	var got HealthReport
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return rec.Code, got
+}
+
+func TestHealthz(t *testing.T) {
+	h := NewHandlers(&healthRepo{pingErr: errors.New("down")}, "")
+	rec := httptest.NewRecorder()
+	h.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status: got %d", rec.Code)
+	}
+}
+
+func TestReadyz(t *testing.T) {
+	code, got := readyz(t, NewHandlers(infastructure.NewServiceRepoMem(), ""))
+	if code != http.StatusOK || got.Status != HealthOK || len(got.Checks) != 1 || got.Checks[0].Name != "database" {
+		t.Fatalf("memory: got %d %+v", code, got)
+	}
+
+	repo := &healthRepo{version: 2}
+	h := NewHandlers(repo, "")
+	h.SchemaVersion = 2
+	code, got = readyz(t, h)
+	if code != http.StatusOK || len(got.Checks) != 2 {
+		t.Fatalf("healthy: got %d %+v", code, got)
+	}
+
+	tests := map[string]func(){
+		"ping":  func() { repo.pingErr = errors.New("connection refused") },
+		"old":   func() { repo.version = 1 },
+		"dirty": func() { repo.dirty = true },
+	}
+	for name, breakRepo := range tests {
+		t.Run(name, func(t *testing.T) {
+			*repo = healthRepo{version: 2}
+			breakRepo()
+			code, got := readyz(t, h)
+			if code != http.StatusServiceUnavailable || got.Status != HealthFail {
+				t.Fatalf("got %d %+v", code, got)
+			}
+			failed := 0
+			for _, c := range got.Checks {
+				if c.Status == HealthFail {
+					failed++
+					if c.Error == "" {
+						t.Fatalf("check %s has no error", c.Name)
+					}
+				}
+			}
+			if failed != 1 {
+				t.Fatalf("failed checks: got %d, want 1: %+v", failed, got.Checks)
+			}
+		})
+	}
+}
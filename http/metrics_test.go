@@ -0,0 +1,39 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/animans/REST-API-test-task/infastructure"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestMetricsRouteTemplate(t *testing.T) {
+	h := NewHandlers(infastructure.NewServiceRepoMem(), "")
+	h.Metrics = NewMetrics(prometheus.NewRegistry())
+	router := h.Router()
+
+	for _, path := range []string{"/service/1", "/service/2", "/service"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status: got %d", rec.Code)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		`http_requests_total{method="GET",route="/service/{id}",status="4xx"} 2`,
+		`http_requests_total{method="GET",route="/service",status="2xx"} 1`,
+		`http_request_duration_seconds_count{method="GET",route="/service/{id}"} 2`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("metrics missing %q:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, `route="/service/1"`) {
+		t.Fatal("raw path used as route label")
+	}
+}
@@ -0,0 +1,144 @@
+package http
+
+import (
+	"bufio"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/animans/REST-API-test-task/logging"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDHeader ...
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLen caps client supplied request ids.
+const maxRequestIDLen = 128
+
+// requestID returns the client X-Request-ID when it is sane, or a new uuid.
+func requestID(r *http.Request) string {
+	id := r.Header.Get(RequestIDHeader)
+	if id == "" || len(id) > maxRequestIDLen {
+		return uuid.NewString()
+	}
+	for _, c := range id {
+		if c < 0x21 || c > 0x7e {
+			return uuid.NewString()
+		}
+	}
+	return id
+}
+
+// routeTemplate returns the matched mux route template, not the raw path.
+func routeTemplate(r *http.Request) string {
+	if cr := mux.CurrentRoute(r); cr != nil {
+		if tpl, err := cr.GetPathTemplate(); err == nil {
+			return tpl
+		}
+	}
+	return "unmatched"
+}
+
+// RequestLogger assigns or propagates X-Request-ID, stores a request-scoped logger
+// in the context and writes one access-log line per request.
+func RequestLogger(base *slog.Logger) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			id := requestID(r)
+			w.Header().Set(RequestIDHeader, id)
+
+			log := base.With(
+				"request_id", id,
+				"method", r.Method,
+				"route", routeTemplate(r),
+				"remote_addr", r.RemoteAddr,
+			)
+			if sc := trace.SpanContextFromContext(r.Context()); sc.IsValid() {
+				log = log.With("trace_id", sc.TraceID().String())
+			}
+			ctx := logging.WithRequestID(r.Context(), id)
+			ctx = logging.WithLogger(ctx, log)
+
+			sw := newStatusWriter(w)
+			aborted := serve(next, sw, r.WithContext(ctx))
+
+			level := slog.LevelInfo
+			if aborted {
+				level = slog.LevelError
+			}
+			log.Log(ctx, level, "access",
+				"path", r.URL.Path,
+				"status", sw.status,
+				"bytes", sw.bytes,
+				"duration_ms", float64(time.Since(start).Microseconds())/1000,
+				"aborted", aborted,
+			)
+			if aborted {
+				panic(http.ErrAbortHandler)
+			}
+		})
+	}
+}
+
+// serve runs next and reports whether it aborted the response with
+// http.ErrAbortHandler, so the caller can record the request before panicking
+// again. Other panics pass through.
+func serve(next http.Handler, w http.ResponseWriter, r *http.Request) (aborted bool) {
+	defer func() {
+		if v := recover(); v != nil {
+			if v != http.ErrAbortHandler {
+				panic(v)
+			}
+			aborted = true
+		}
+	}()
+	next.ServeHTTP(w, r)
+	return false
+}
+
+// statusWriter captures the response status and size.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+// newStatusWriter ...
+func newStatusWriter(w http.ResponseWriter) *statusWriter {
+	return &statusWriter{ResponseWriter: w, status: http.StatusOK}
+}
+
+// WriteHeader ...
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write ...
+func (w *statusWriter) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += n
+	return n, err
+}
+
+// Flush keeps streaming responses working through the wrapper.
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack ...
+func (w *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,65 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/animans/REST-API-test-task/infastructure"
+)
+
+func logLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var out []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var m map[string]any
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			t.Fatalf("decode %q: %v", line, err)
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+func TestRequestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandlers(infastructure.NewServiceRepoMem(), "")
+	h.Logger = slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	router := h.Router()
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/service/7", nil)
+	req.Header.Set(RequestIDHeader, "req-123")
+	router.ServeHTTP(rec, req)
+	if got := rec.Header().Get(RequestIDHeader); got != "req-123" {
+		t.Fatalf("X-Request-ID: got %q", got)
+	}
+
+	lines := logLines(t, &buf)
+	if len(lines) < 2 {
+		t.Fatalf("expected handler and access lines, got %d", len(lines))
+	}
+	for _, l := range lines {
+		if l["request_id"] != "req-123" || l["route"] != "/service/{id}" || l["method"] != "GET" {
+			t.Fatalf("line without request attributes: %v", l)
+		}
+	}
+	access := lines[len(lines)-1]
+	if access["msg"] != "access" || access["status"] != float64(http.StatusNotFound) || access["bytes"].(float64) == 0 {
+		t.Fatalf("access line: %v", access)
+	}
+
+	for _, sent := range []string{"", "bad id", strings.Repeat("x", maxRequestIDLen+1)} {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+		req.Header.Set(RequestIDHeader, sent)
+		router.ServeHTTP(rec, req)
+		if got := rec.Header().Get(RequestIDHeader); got == "" || got == sent {
+			t.Fatalf("X-Request-ID for %q: got %q", sent, got)
+		}
+	}
+}
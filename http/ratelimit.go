@@ -0,0 +1,94 @@
+package http
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/animans/REST-API-test-task/auth"
+	"github.com/animans/REST-API-test-task/logging"
+	"github.com/animans/REST-API-test-task/ratelimit"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// RateLimit throttles each client per route and answers 429 once its bucket is empty.
+// Store errors let the request through.
+func RateLimit(l *ratelimit.Limiter) mux.MiddlewareFunc {
+	return limitBy(func(r *http.Request) (ratelimit.Result, error) {
+		return l.Allow(r.Context(), routeTemplate(r), clientKey(r, l.TrustProxy))
+	})
+}
+
+// RateLimitAuth throttles each client address before authentication, so
+// requests with wrong credentials use up tokens too.
+func RateLimitAuth(l *ratelimit.Limiter) mux.MiddlewareFunc {
+	return limitBy(func(r *http.Request) (ratelimit.Result, error) {
+		return l.AllowAuth(r.Context(), clientIP(r, l.TrustProxy))
+	})
+}
+
+// limitBy answers 429 when take refuses the request.
+func limitBy(take func(r *http.Request) (ratelimit.Result, error)) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			res, err := take(r)
+			if err != nil {
+				logging.FromContext(r.Context()).Warn("rate limit store error", "err", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+			h := w.Header()
+			h.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+			h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			h.Set("RateLimit-Reset", ceilSeconds(res.Reset))
+			if !res.Allowed {
+				h.Set("Retry-After", ceilSeconds(res.RetryAfter))
+				logging.FromContext(r.Context()).Warn("rate limited", "retry_after", res.RetryAfter)
+				writeProblem(w, r, http.StatusTooManyRequests, "rate limit exceeded")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// clientKey prefers the API key, then the user, then the client address.
+func clientKey(r *http.Request, trustProxy bool) string {
+	if p, ok := auth.FromContext(r.Context()); ok {
+		if p.KeyID != uuid.Nil {
+			return "key:" + p.KeyID.String()
+		}
+		if p.UserID != uuid.Nil {
+			return "user:" + p.UserID.String()
+		}
+	}
+	return clientIP(r, trustProxy)
+}
+
+// clientIP keys by the peer address, or behind a trusted proxy by the last
+// X-Forwarded-For hop: the proxy appends it, the client controls the others.
+func clientIP(r *http.Request, trustProxy bool) string {
+	if fwd := r.Header.Values("X-Forwarded-For"); trustProxy && len(fwd) > 0 {
+		last := fwd[len(fwd)-1]
+		if i := strings.LastIndexByte(last, ','); i >= 0 {
+			last = last[i+1:]
+		}
+		if ip := net.ParseIP(strings.TrimSpace(last)); ip != nil {
+			return "ip:" + ip.String()
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// ceilSeconds formats d as whole delta-seconds, rounded up.
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,148 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/animans/REST-API-test-task/auth"
+	"github.com/animans/REST-API-test-task/config"
+	"github.com/animans/REST-API-test-task/domain"
+	"github.com/animans/REST-API-test-task/infastructure"
+	"github.com/animans/REST-API-test-task/ratelimit"
+	"github.com/google/uuid"
+)
+
+func TestRateLimit(t *testing.T) {
+	h := NewHandlers(infastructure.NewServiceRepoMem(), "")
+	h.RateLimiter = ratelimit.New(ratelimit.NewMemoryStore(), config.RateLimit{
+		Default: config.Limit{RPS: 0.01, Burst: 2},
+		Routes:  map[string]config.Limit{"/service/summary": {RPS: 0.01, Burst: 1}},
+	})
+	router := h.Router()
+	get := func(target, remote string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.RemoteAddr = remote
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := get("/service", "10.0.0.1:1000")
+	wantStatus(t, rec, http.StatusOK)
+	if rec.Header().Get("RateLimit-Limit") != "2" || rec.Header().Get("RateLimit-Remaining") != "1" {
+		t.Fatalf("headers: %v", rec.Header())
+	}
+	wantStatus(t, get("/service", "10.0.0.1:1001"), http.StatusOK)
+	rec = get("/service", "10.0.0.1:1002")
+	wantStatus(t, rec, http.StatusTooManyRequests)
+	wantBodyContains(t, rec, "/problems/rate-limited")
+	if rec.Header().Get("Retry-After") != "100" || rec.Header().Get("RateLimit-Remaining") != "0" {
+		t.Fatalf("429 headers: %v", rec.Header())
+	}
+
+	wantStatus(t, get("/service", "10.0.0.2:1000"), http.StatusOK)
+	wantStatus(t, get("/service/summary", "10.0.0.2:1000"), http.StatusOK)
+	wantStatus(t, get("/service/summary", "10.0.0.2:1000"), http.StatusTooManyRequests)
+	wantStatus(t, get("/healthz", "10.0.0.1:1000"), http.StatusOK)
+}
+
+func TestRateLimitBeforeAuth(t *testing.T) {
+	h := NewHandlers(infastructure.NewServiceRepoMem(), "")
+	h.Auth = tokenAuth{"good": {UserID: uuid.New(), Scopes: domain.Scopes}}
+	h.RateLimiter = ratelimit.New(ratelimit.NewMemoryStore(), config.RateLimit{
+		Default: config.Limit{RPS: 0.01, Burst: 10},
+		Auth:    config.Limit{RPS: 0.01, Burst: 3},
+	})
+	router := h.Router()
+	get := func(target, token, remote string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.RemoteAddr = remote
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for i := 0; i < 3; i++ {
+		wantStatus(t, get("/service", "guess", "10.0.0.1:1000"), http.StatusUnauthorized)
+	}
+	rec := get("/service", "guess", "10.0.0.1:1000")
+	wantStatus(t, rec, http.StatusTooManyRequests)
+	if rec.Header().Get("Retry-After") != "100" {
+		t.Fatalf("429 headers: %v", rec.Header())
+	}
+	// The address stays blocked even with a valid token.
+	wantStatus(t, get("/service", "good", "10.0.0.1:1000"), http.StatusTooManyRequests)
+	wantStatus(t, get("/service", "good", "10.0.0.2:1000"), http.StatusOK)
+}
+
+func TestRateLimitSpoofedForwardedFor(t *testing.T) {
+	h := NewHandlers(infastructure.NewServiceRepoMem(), "")
+	h.Auth = tokenAuth{}
+	h.RateLimiter = ratelimit.New(ratelimit.NewMemoryStore(), config.RateLimit{
+		TrustProxy: true,
+		Default:    config.Limit{RPS: 0.01, Burst: 10},
+		Auth:       config.Limit{RPS: 0.01, Burst: 2},
+	})
+	router := h.Router()
+
+	// Each request claims another origin on the left; the proxy appends the real one.
+	var rec *httptest.ResponseRecorder
+	for i := range 3 {
+		req := httptest.NewRequest(http.MethodGet, "/service", nil)
+		req.RemoteAddr = "10.0.0.254:4000"
+		req.Header.Set("X-Forwarded-For", fmt.Sprintf("192.0.2.%d, 203.0.113.9", i+1))
+		rec = httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+	}
+	wantStatus(t, rec, http.StatusTooManyRequests)
+	if rec.Header().Get("Retry-After") == "" {
+		t.Fatalf("429 headers: %v", rec.Header())
+	}
+}
+
+func TestClientKey(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/service", nil)
+	req.RemoteAddr = "192.0.2.7:5555"
+	req.Header.Set("X-Forwarded-For", "203.0.113.9, 10.0.0.1")
+	if got := clientKey(req, false); got != "ip:192.0.2.7" {
+		t.Fatalf("peer: got %q", got)
+	}
+	if got := clientKey(req, true); got != "ip:10.0.0.1" {
+		t.Fatalf("forwarded: got %q", got)
+	}
+	req.Header.Add("X-Forwarded-For", "198.51.100.4")
+	if got := clientKey(req, true); got != "ip:198.51.100.4" {
+		t.Fatalf("second header: got %q", got)
+	}
+
+	user, key := uuid.New(), uuid.New()
+	ctx := auth.WithPrincipal(req.Context(), auth.Principal{UserID: user})
+	if got := clientKey(req.WithContext(ctx), true); got != "user:"+user.String() {
+		t.Fatalf("user: got %q", got)
+	}
+	ctx = auth.WithPrincipal(req.Context(), auth.Principal{UserID: user, KeyID: key, Scopes: []string{domain.ScopeRead}})
+	if got := clientKey(req.WithContext(ctx), true); got != "key:"+key.String() {
+		t.Fatalf("api key: got %q", got)
+	}
+}
+
+// failingStore always errors.
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, ratelimit.Limit, time.Time) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("store down")
+}
+
+func TestRateLimitStoreError(t *testing.T) {
+	h := NewHandlers(infastructure.NewServiceRepoMem(), "")
+	h.RateLimiter = &ratelimit.Limiter{Store: failingStore{}}
+	rec := httptest.NewRecorder()
+	h.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/service", nil))
+	wantStatus(t, rec, http.StatusOK)
+}
@@ -1,19 +1,53 @@
 package http
 
 import (
+	"github.com/animans/REST-API-test-task/domain"
 	"github.com/gorilla/mux"
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
 // Register ...
 func Register(r *mux.Router, h *Handlers) {
+	if h.TracerProvider != nil {
+		r.Use(Tracing(h.TracerProvider))
+	}
+	r.Use(RequestLogger(h.logger()))
+	if h.Metrics != nil {
+		r.Use(h.Metrics.Middleware)
+		r.Handle("/metrics", h.Metrics.Handler()).Methods("GET")
+	}
 	api := r.NewRoute().Subrouter()
+	if h.Auth != nil {
+		if h.RateLimiter != nil {
+			api.Use(RateLimitAuth(h.RateLimiter))
+		}
+		api.Use(Authenticate(h.Auth), methodScope)
+	}
+	if h.RateLimiter != nil {
+		api.Use(RateLimit(h.RateLimiter))
+	}
 	r.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
+	r.HandleFunc("/healthz", h.Healthz).Methods("GET")
+	r.HandleFunc("/readyz", h.Readyz).Methods("GET")
 	api.HandleFunc("/service", h.Create).Methods("POST")
 	api.HandleFunc("/service", h.List).Methods("GET")
+	api.HandleFunc("/service/import", h.Import).Methods("POST")
+	api.HandleFunc("/service/export", h.Export).Methods("GET")
+	api.HandleFunc("/service/batch", h.Batch).Methods("POST")
 	api.HandleFunc("/service/summary", h.ListSum).Methods("GET")
+	api.HandleFunc("/service/summary/monthly", h.ListSumMonthly).Methods("GET")
 	api.HandleFunc("/service/{id}", h.Get).Methods("GET")
 	api.HandleFunc("/service/{id}", h.Put).Methods("PUT")
 	api.HandleFunc("/service/{id}", h.Delete).Methods("DELETE")
 
+	if h.Auth != nil && h.APIKeys != nil {
+		admin := r.PathPrefix("/admin").Subrouter()
+		if h.RateLimiter != nil {
+			admin.Use(RateLimitAuth(h.RateLimiter))
+		}
+		admin.Use(Authenticate(h.Auth), RequireScope(domain.ScopeAdmin))
+		admin.HandleFunc("/api-keys", h.CreateAPIKey).Methods("POST")
+		admin.HandleFunc("/api-keys", h.ListAPIKeys).Methods("GET")
+		admin.HandleFunc("/api-keys/{id}", h.RevokeAPIKey).Methods("DELETE")
+	}
 }
@@ -0,0 +1,82 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// ServerOptions configures the http.Server started by Handlers.Start.
+type ServerOptions struct {
+	Addr              string
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	MaxHeaderBytes    int
+	// ShutdownTimeout bounds how long in-flight requests may drain after the context is done.
+	ShutdownTimeout time.Duration
+}
+
+// Router ...
+func (h *Handlers) Router() http.Handler {
+	router := mux.NewRouter()
+	Register(router, h)
+	return router
+}
+
+// NewServer ...
+func NewServer(handler http.Handler, o ServerOptions) *http.Server {
+	return &http.Server{
+		Addr:              o.Addr,
+		Handler:           handler,
+		ReadTimeout:       o.ReadTimeout,
+		ReadHeaderTimeout: o.ReadHeaderTimeout,
+		WriteTimeout:      o.WriteTimeout,
+		IdleTimeout:       o.IdleTimeout,
+		MaxHeaderBytes:    o.MaxHeaderBytes,
+	}
+}
+
+// Start serves the API until ctx is done, then shuts down gracefully.
+func (h *Handlers) Start(ctx context.Context, o ServerOptions) error {
+	ln, err := net.Listen("tcp", o.Addr)
+	if err != nil {
+		return err
+	}
+	return Serve(ctx, ln, NewServer(h.Router(), o), o.ShutdownTimeout)
+}
+
+// Serve runs srv on ln until ctx is done, then drains in-flight requests for up to timeout.
+func Serve(ctx context.Context, ln net.Listener, srv *http.Server, timeout time.Duration) error {
+	errc := make(chan error, 1)
+	go func() {
+		slog.Info("Starting api", "addr", ln.Addr().String())
+		errc <- srv.Serve(ln)
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("Shutting down api", "timeout", timeout)
+	sctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := srv.Shutdown(sctx); err != nil {
+		slog.Error("Shutdown error", "err", err)
+		_ = srv.Close()
+		return err
+	}
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	slog.Info("Shutdown done")
+	return nil
+}
@@ -1,54 +1,78 @@
 package http
 
 import (
+	"crypto/rand"
 	"encoding/json"
+	"fmt"
 	"log/slog"
 	"net/http"
-	"os"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/animans/REST-API-test-task/auth"
 	"github.com/animans/REST-API-test-task/domain"
+	"github.com/animans/REST-API-test-task/logging"
+	"github.com/animans/REST-API-test-task/ratelimit"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Handlers ...
 type Handlers struct {
-	Repo domain.ServiceRepository
+	Repo    domain.ServiceRepository
+	Cursors *domain.CursorCodec
+	// ReadyTimeout bounds the readiness checks.
+	ReadyTimeout time.Duration
+	// SchemaVersion is the minimum migration version /readyz accepts, zero skips the check.
+	SchemaVersion int64
+	// Metrics enables /metrics and per-route instrumentation when set.
+	Metrics *Metrics
+	// Logger is the base of request-scoped loggers, slog.Default when nil.
+	Logger *slog.Logger
+	// TracerProvider enables a server span per request when set.
+	TracerProvider trace.TracerProvider
+	// Auth requires credentials on /service routes when set; non-admin callers only see their own services.
+	Auth auth.Authenticator
+	// APIKeys enables the /admin/api-keys endpoints when set together with Auth.
+	APIKeys domain.APIKeyRepository
+	// RateLimiter throttles /service routes per client when set.
+	RateLimiter *ratelimit.Limiter
+	// MaxImportBytes caps the body of /service/import, DefaultMaxImportBytes when zero.
+	MaxImportBytes int64
+	// ExportTimeout replaces the server write deadline on /service/export, zero clears it.
+	ExportTimeout time.Duration
 }
 
-// NewHandlers ...
-func NewHandlers(repo domain.ServiceRepository) *Handlers {
+// NewHandlers signs cursors with cursorSecret; an empty secret uses a random per-process key.
+func NewHandlers(repo domain.ServiceRepository, cursorSecret string) *Handlers {
 	return &Handlers{
-		Repo: repo,
+		Repo:           repo,
+		Cursors:        domain.NewCursorCodec(cursorKey(cursorSecret)),
+		ReadyTimeout:   DefaultReadyTimeout,
+		MaxImportBytes: DefaultMaxImportBytes,
+		ExportTimeout:  DefaultExportTimeout,
 	}
 }
 
-// CreatedResponseID
-type CreatedResponseID struct {
-	ID int `json:"id"`
-}
-
-// CreatedResponse
-type CreatedResponse struct {
-	Name      string `json:"service_name"`
-	Price     int    `json:"price"`
-	Uuid      string `json:"user_id"`
-	StartDate string `json:"start_date"`
+// logger ...
+func (h *Handlers) logger() *slog.Logger {
+	if h.Logger != nil {
+		return h.Logger
+	}
+	return slog.Default()
 }
 
-// Start ...
-func (h *Handlers) Start() error {
-	env, ok := os.LookupEnv("BIND_ADDR")
-	if !ok {
-		env = "8080"
-	}
-	router := mux.NewRouter()
-	Register(router, h)
-	slog.Info("Starting api")
-	return http.ListenAndServe(env, router)
+// cursorKey ...
+func cursorKey(secret string) []byte {
+	if secret != "" {
+		return []byte(secret)
+	}
+	key := make([]byte, 32)
+	_, _ = rand.Read(key)
+	return key
 }
 
 // Create
@@ -58,56 +82,53 @@ func (h *Handlers) Start() error {
 // @Accept       json
 // @Produce      json
 // @Param        input body     domain.CreatedRequest true "service payload"
-// @Success      201   {object} domain.CreatedRequest
-// @Failure      400   {string} string "bad request"
-// @Failure      500   {string} string "internal error"
+// @Success      201   {object} domain.ServiceResponse
+// @Header       201   {string} Location "/service/{id}"
+// @Failure      400   {object} Problem
+// @Failure      401   {object} Problem
+// @Failure      403   {object} Problem
+// @Failure      409   {object} Problem
+// @Failure      422   {object} Problem
+// @Failure      429   {object} Problem
+// @Failure      500   {object} Problem
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Router       /service [post]
 func (h *Handlers) Create(w http.ResponseWriter, r *http.Request) {
-	slog.Info("Create start")
+	log := logging.FromContext(r.Context())
+	log.Info("Create start")
 	var in domain.CreatedRequest
 	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
-		slog.Error("invalid json", "err", err)
-		http.Error(w, "invalid json", http.StatusBadRequest)
-		return
-	}
-	if strings.TrimSpace(in.Name) == "" {
-		slog.Error("invalid name", "name", in.Name)
-		http.Error(w, "name required", http.StatusBadRequest)
-		return
-	}
-	if in.Price < 0 {
-		slog.Error("invalid price", "price", in.Price)
-		http.Error(w, "price must be >= 0", http.StatusBadRequest)
+		log.Error("invalid json", "err", err)
+		writeProblem(w, r, http.StatusBadRequest, "invalid json")
 		return
 	}
 
-	sdate, err := time.Parse("01-2006", in.StartDate)
+	ser, err := in.ToService()
 	if err != nil {
-		slog.Error("invalid sdate", "err", err)
-		http.Error(w, "invalid time", http.StatusBadRequest)
+		log.Error("invalid request", "err", err)
+		writeError(w, r, err)
 		return
 	}
-
-	uuid, err := uuid.Parse(in.Uuid)
-	if err != nil {
-		slog.Error("invalid uuid", "uuid", uuid)
-		http.Error(w, "invalid uuid", http.StatusBadRequest)
+	if err := checkOwner(r.Context(), ser); err != nil {
+		log.Error("forbidden", "err", err)
+		writeError(w, r, err)
 		return
 	}
 
-	ser := domain.NewService(in.Name, in.Price, uuid, sdate)
-	id, err := h.Repo.Save(ser)
+	id, err := h.Repo.Save(r.Context(), ser)
 	if err != nil {
-		slog.Error("invalid id", "err", err)
-		http.Error(w, "save error", http.StatusInternalServerError)
+		log.Error("save error", "err", err)
+		writeError(w, r, err)
 		return
 	}
 
-	w.WriteHeader(http.StatusCreated)
+	out := domain.NewServiceResponse(ser)
 	w.Header().Set("Content-Type", "application/json")
-	out := CreatedResponseID{ID: id}
+	w.Header().Set("Location", "/service/"+strconv.Itoa(id))
+	w.WriteHeader(http.StatusCreated)
 	_ = json.NewEncoder(w).Encode(out)
-	slog.Info("Create done", "out", out)
+	log.Info("Create done", "out", out)
 }
 
 // Get
@@ -115,102 +136,123 @@ func (h *Handlers) Create(w http.ResponseWriter, r *http.Request) {
 // @Tags         service
 // @Produce      json
 // @Param        id   path integer true "Service ID" format(integer)
-// @Success      200  {object} domain.CreatedRequest
-// @Failure      404  {string} string "not found"
+// @Success      200  {object} domain.ServiceResponse
+// @Failure      400  {object} Problem
+// @Failure      401  {object} Problem
+// @Failure      403  {object} Problem
+// @Failure      404  {object} Problem
+// @Failure      429  {object} Problem
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Router       /service/{id} [get]
 func (h *Handlers) Get(w http.ResponseWriter, r *http.Request) {
-	slog.Info("Get start", "mux.Vars(r)", mux.Vars(r))
+	log := logging.FromContext(r.Context())
+	log.Info("Get start", "mux.Vars(r)", mux.Vars(r))
 	id := mux.Vars(r)["id"]
-	ser, err := h.Repo.GetByID(id)
+	ser, err := h.Repo.GetByID(r.Context(), id)
+	if err == nil && !visible(r.Context(), ser) {
+		err = fmt.Errorf("%w: id=%s", domain.ErrNotFound, id)
+	}
 	if err != nil {
-		slog.Error("invalid id", "err", err)
-		http.Error(w, "invalid id", http.StatusBadRequest)
+		log.Error("get error", "err", err)
+		writeError(w, r, err)
 		return
 	}
-	out := CreatedResponse{
-		Name:      ser.GetName(),
-		Price:     ser.GetPrice(),
-		Uuid:      ser.GetUUID().String(),
-		StartDate: ser.GetStartDate().Format("01-2006"),
-	}
-	w.WriteHeader(http.StatusOK)
+	out := domain.NewServiceResponse(ser)
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
 	_ = json.NewEncoder(w).Encode(out)
-	slog.Info("Get done", "out", out)
+	log.Info("Get done", "out", out)
 }
 
 // Update
 // @Summary      Update service
 // @Tags         service
 // @Accept       json
+// @Produce      json
 // @Param        id    path  integer                 true "Service ID" format(integer)
 // @Param        input body  domain.CreatedRequest  true "update payload"
 // @Success      204
-// @Failure      400   {string} string "bad request"
-// @Failure      404   {string} string "not found"
+// @Failure      400   {object} Problem
+// @Failure      401   {object} Problem
+// @Failure      403   {object} Problem
+// @Failure      404   {object} Problem
+// @Failure      422   {object} Problem
+// @Failure      429   {object} Problem
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Router       /service/{id} [put]
 func (h *Handlers) Put(w http.ResponseWriter, r *http.Request) {
-	slog.Info("Put start", "mux.Vars(r)", mux.Vars(r))
+	log := logging.FromContext(r.Context())
+	log.Info("Put start", "mux.Vars(r)", mux.Vars(r))
 	id := mux.Vars(r)["id"]
 	var in domain.CreatedRequest
 	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
-		slog.Error("invalid json", "err", err)
-		http.Error(w, "invalid json", http.StatusBadRequest)
-		return
-	}
-
-	if strings.TrimSpace(in.Name) == "" {
-		slog.Error("invalid name", "name", in.Name)
-		http.Error(w, "name required", http.StatusBadRequest)
-		return
-	}
-	if in.Price < 0 {
-		slog.Error("invalid price", "price", in.Price)
-		http.Error(w, "price must be >= 0", http.StatusBadRequest)
+		log.Error("invalid json", "err", err)
+		writeProblem(w, r, http.StatusBadRequest, "invalid json")
 		return
 	}
 
-	sdate, err := time.Parse("01-2006", in.StartDate)
+	ser, err := in.ToService()
 	if err != nil {
-		slog.Error("invalid sdate", "err", err)
-		http.Error(w, "invalid date (want MM-YYYY)", http.StatusBadRequest)
+		log.Error("invalid request", "err", err)
+		writeError(w, r, err)
 		return
 	}
-
-	uuid, err := uuid.Parse(in.Uuid)
-	if err != nil {
-		slog.Error("invalid uuid", "err", err)
-		http.Error(w, "invalid uuid", http.StatusBadRequest)
+	if err := checkOwner(r.Context(), ser); err != nil {
+		log.Error("forbidden", "err", err)
+		writeError(w, r, err)
 		return
 	}
 
-	ser := domain.NewService(in.Name, in.Price, uuid, sdate)
-	if err := h.Repo.UpdateByID(id, ser); err != nil {
-		slog.Error("update error", "err", err)
-		http.Error(w, "update error", http.StatusInternalServerError)
+	// The owner check and the write share a transaction, so the row cannot change in between.
+	err = h.Repo.WithTx(r.Context(), func(tx domain.ServiceRepository) error {
+		if err := authorizeIn(r.Context(), tx, id); err != nil {
+			return err
+		}
+		return tx.UpdateByID(r.Context(), id, ser)
+	})
+	if err != nil {
+		log.Error("update error", "err", err)
+		writeError(w, r, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
-	slog.Info("Put done")
+	log.Info("Put done")
 }
 
 // Delete
 // @Summary      Delete service
 // @Tags         service
+// @Produce      json
 // @Param        id path integer true "Service ID" format(integer)
 // @Success      204 {string} string "deleted"
-// @Failure      404 {string} string "not found"
+// @Failure      400 {object} Problem
+// @Failure      401 {object} Problem
+// @Failure      403 {object} Problem
+// @Failure      404 {object} Problem
+// @Failure      429 {object} Problem
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Router       /service/{id} [delete]
 func (h *Handlers) Delete(w http.ResponseWriter, r *http.Request) {
-	slog.Info("Delete start", "mux.Vars(r)", mux.Vars(r))
+	log := logging.FromContext(r.Context())
+	log.Info("Delete start", "mux.Vars(r)", mux.Vars(r))
 	id := mux.Vars(r)["id"]
-	if err := h.Repo.DeleteByID(id); err != nil {
-		slog.Error("delete error", "err", err)
-		http.Error(w, "delete error", http.StatusBadRequest)
+	err := h.Repo.WithTx(r.Context(), func(tx domain.ServiceRepository) error {
+		if err := authorizeIn(r.Context(), tx, id); err != nil {
+			return err
+		}
+		return tx.DeleteByID(r.Context(), id)
+	})
+	if err != nil {
+		log.Error("delete error", "err", err)
+		writeError(w, r, err)
+		return
 	}
 	w.WriteHeader(http.StatusNoContent)
-	slog.Info("Delete done")
+	log.Info("Delete done")
 }
 
 // List
@@ -220,74 +262,40 @@ func (h *Handlers) Delete(w http.ResponseWriter, r *http.Request) {
 // @Param        name    query string false "filter by service name (contains)"
 // @Param        user_id query string false "User UUID" format(uuid)
 // @Param        price   query string false "Price"
-// @Param        from    query string false "From month (MM-YYYY)" example(01-2024)
-// @Param        to      query string false "To month   (MM-YYYY)" example(03-2024)
+// @Param        from    query string false "active from month (MM-YYYY)" example(01-2024)
+// @Param        to      query string false "active to month (MM-YYYY)" example(03-2024)
 // @Param        sort    query string false "sort by (service_created_at, service_price, service_name)"
 // @Param        dir     query string false "order by (asc, desc)"
 // @Param        limit   query string false "limit  (1 <= limit <= 100)" example(50)
+// @Param        cursor  query string false "next_cursor or prev_cursor of a previous page with the same sort and dir"
+// @Param        total   query bool   false "also count all matching services"
 // @Success      200 {object} domain.ListResult
+// @Failure      400 {object} Problem
+// @Failure      401 {object} Problem
+// @Failure      403 {object} Problem
+// @Failure      429 {object} Problem
+// @Failure      500 {object} Problem
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Router       /service [get]
 func (h *Handlers) List(w http.ResponseWriter, r *http.Request) {
-	slog.Info("List start", "r.URL.Query()", r.URL.Query())
+	log := logging.FromContext(r.Context())
+	log.Info("List start", "r.URL.Query()", r.URL.Query())
 	q := r.URL.Query()
-	var f domain.ListFilterService
-
-	if name := q.Get("name"); name != "" {
-		f.Name = name
-	}
-
-	if s := q.Get("user_id"); s != "" {
-		user_id, err := uuid.Parse(s)
-		if err != nil {
-			slog.Error("invalid user_id", "err", err)
-			http.Error(w, "bad user_id", http.StatusBadRequest)
-			return
-		}
-		f.Uuid = &user_id
+	var verr domain.ValidationError
+	f := parseListFilter(q, &verr)
+	if err := verr.Err(); err != nil {
+		log.Error("invalid query", "err", err)
+		writeBadRequest(w, r, &verr)
+		return
 	}
-
-	if s := q.Get("price"); s != "" {
-		price, err := strconv.Atoi(s)
-		if err != nil {
-			slog.Error("invalid price", "err", err)
-			http.Error(w, "bad price", http.StatusBadRequest)
-			return
-		}
-		f.Price = price
+	var err error
+	if f.Uuid, err = scopeUser(r.Context(), f.Uuid); err != nil {
+		log.Error("forbidden", "err", err)
+		writeError(w, r, err)
+		return
 	}
 
-	if s := q.Get("from"); s != "" {
-		fromStartDate, err := time.Parse("01-2006", s)
-		if err != nil {
-			slog.Error("invalid fromStartDate", "err", err)
-			http.Error(w, "bad fromDate (MM-YYY)", http.StatusBadRequest)
-			return
-		}
-		f.FromStartDate = &fromStartDate
-	}
-	if s := q.Get("to"); s != "" {
-		toStartDate, err := time.Parse("01-2006", s)
-		if err != nil {
-			slog.Error("invalid ToStartDate", "err", err)
-			http.Error(w, "bad toDate (MM-YYY)", http.StatusBadRequest)
-			return
-		}
-		f.ToStartDate = &toStartDate
-	}
-	s := strings.ToLower(q.Get("sort"))
-	switch s {
-	case "service_created_at", "service_price", "service_name":
-		f.SortBy = s
-	default:
-		f.SortBy = "service_created_at"
-	}
-	s = strings.ToLower(q.Get("dir"))
-	switch s {
-	case "asc", "desc":
-		f.SortDir = s
-	default:
-		f.SortDir = "desc"
-	}
 	if l := q.Get("limit"); l != "" {
 		n, _ := strconv.Atoi(l)
 		if n < 1 {
@@ -300,77 +308,232 @@ func (h *Handlers) List(w http.ResponseWriter, r *http.Request) {
 	} else {
 		f.Limit = 50
 	}
+	f.WithTotal, _ = strconv.ParseBool(q.Get("total"))
+	if tok := q.Get("cursor"); tok != "" {
+		c, err := h.Cursors.Decode(tok)
+		if err != nil || c.SortBy != f.SortBy || c.SortDir != f.SortDir {
+			log.Error("invalid cursor", "err", err)
+			verr.Add("cursor", "invalid or does not match sort and dir")
+			writeBadRequest(w, r, &verr)
+			return
+		}
+		f.Cursor = &c
+	}
 
-	res, err := h.Repo.ListByFilter(f)
+	res, err := h.Repo.ListByFilter(r.Context(), f)
 	if err != nil {
-		slog.Error("invalid res", "err", err)
-		http.Error(w, "internal err", http.StatusInternalServerError)
+		log.Error("invalid res", "err", err)
+		writeError(w, r, err)
 		return
 	}
+	if res.Next != nil {
+		res.NextCursor = h.Cursors.Encode(*res.Next)
+	}
+	if res.Prev != nil {
+		res.PrevCursor = h.Cursors.Encode(*res.Prev)
+	}
 
-	w.WriteHeader(http.StatusOK)
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
 	_ = json.NewEncoder(w).Encode(res)
-	slog.Info("List done", "res", res)
+	log.Info("List done", "items", len(res.Items))
 }
 
 // Summary
 // @Summary      Sum price by period
-// @Description  Суммарная стоимость подписок за период с фильтрами
+// @Description  Суммарная стоимость подписок за период с фильтрами: цена × число активных месяцев в окне [from, to]; to по умолчанию текущий месяц
 // @Tags         service
 // @Produce      json
 // @Param        name    query string false "service name (contains)"
 // @Param        user_id query string false "User UUID" format(uuid)
-// @Param        from    query string false "From month (MM-YYYY)" example(01-2024)
-// @Param        to      query string false "To month   (MM-YYYY)" example(03-2024)
+// @Param        from    query string false "active from month (MM-YYYY)" example(01-2024)
+// @Param        to      query string false "active to month (MM-YYYY)" example(03-2024)
+// @Param        group_by query string false "group totals by (service_name, user_id), sorted by total desc"
 // @Success      200 {object} domain.SumResult
-// @Failure      400 {string} string "bad request"
+// @Failure      400 {object} Problem
+// @Failure      401 {object} Problem
+// @Failure      403 {object} Problem
+// @Failure      429 {object} Problem
+// @Failure      500 {object} Problem
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Router       /service/summary [get]
 func (h *Handlers) ListSum(w http.ResponseWriter, r *http.Request) {
-	slog.Info("ListSum start", "r.URL.Query()", r.URL.Query())
-	var f domain.SumFilterService
-
+	log := logging.FromContext(r.Context())
+	log.Info("ListSum start", "r.URL.Query()", r.URL.Query())
 	q := r.URL.Query()
+	var verr domain.ValidationError
+	f := parseSumFilter(q, &verr)
+	switch g := q.Get("group_by"); g {
+	case "":
+	case domain.GroupByName, domain.GroupByUser:
+		f.GroupBy = g
+	default:
+		verr.Add("group_by", "want service_name or user_id")
+	}
+	if err := verr.Err(); err != nil {
+		log.Error("invalid query", "err", err)
+		writeBadRequest(w, r, &verr)
+		return
+	}
 
-	if s := q.Get("name"); s != "" {
-		f.Name = s
+	var (
+		out domain.SumResult
+		err error
+	)
+	if f.Uuid, err = scopeUser(r.Context(), f.Uuid); err != nil {
+		log.Error("forbidden", "err", err)
+		writeError(w, r, err)
+		return
 	}
-	if s := q.Get("user_id"); s != "" {
-		uuid, err := uuid.Parse(s)
-		if err != nil {
-			slog.Error("invalid uuid", "err", err)
-			http.Error(w, "bad user_id", http.StatusBadRequest)
-			return
+	if f.GroupBy != "" {
+		out.Groups, err = h.Repo.SumByGroup(r.Context(), f)
+		for _, g := range out.Groups {
+			out.Total += g.Total
 		}
-		f.Uuid = &uuid
+	} else {
+		out, err = h.Repo.SumByFilter(r.Context(), f)
 	}
-	if s := q.Get("from"); s != "" {
-		fromStartDate, err := time.Parse("01-2006", s)
-		if err != nil {
-			slog.Error("invalid fromStartDate", "err", err)
-			http.Error(w, "bad fromDate (MM-YYYY)", http.StatusBadRequest)
-			return
-		}
-		f.FromStartDate = &fromStartDate
+	if err != nil {
+		log.Error("invalid out", "err", err)
+		writeError(w, r, err)
+		return
 	}
-	if s := q.Get("to"); s != "" {
-		toStartDate, err := time.Parse("01-2006", s)
-		if err != nil {
-			slog.Error("invalid toStartDate", "err", err)
-			http.Error(w, "bad toDate (MM-YYYY)", http.StatusBadRequest)
-			return
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(out)
+	log.Info("ListSum", "out", out)
+}
+
+// maxSummaryMonths bounds the monthly breakdown window.
+const maxSummaryMonths = 120
+
+// ListSumMonthly
+// @Summary      Sum price per month
+// @Description  Помесячная разбивка стоимости подписок за период [from, to]
+// @Tags         service
+// @Produce      json
+// @Param        name    query string false "service name (contains)"
+// @Param        user_id query string false "User UUID" format(uuid)
+// @Param        from    query string true  "first month (MM-YYYY)" example(01-2024)
+// @Param        to      query string true  "last month (MM-YYYY)" example(03-2024)
+// @Success      200 {array}  domain.MonthSum
+// @Failure      400 {object} Problem
+// @Failure      401 {object} Problem
+// @Failure      403 {object} Problem
+// @Failure      429 {object} Problem
+// @Failure      500 {object} Problem
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Router       /service/summary/monthly [get]
+func (h *Handlers) ListSumMonthly(w http.ResponseWriter, r *http.Request) {
+	log := logging.FromContext(r.Context())
+	log.Info("ListSumMonthly start", "r.URL.Query()", r.URL.Query())
+	var verr domain.ValidationError
+	f := parseSumFilter(r.URL.Query(), &verr)
+	if f.From == nil && !verr.Has("from") {
+		verr.Add("from", "required")
+	}
+	if f.To == nil && !verr.Has("to") {
+		verr.Add("to", "required")
+	}
+	if f.From != nil && f.To != nil {
+		switch n := domain.MonthSpan(*f.From, *f.To); {
+		case n == 0:
+			verr.Add("to", "must be >= from")
+		case n > maxSummaryMonths:
+			verr.Add("to", fmt.Sprintf("window must not exceed %d months", maxSummaryMonths))
 		}
-		f.ToStartDate = &toStartDate
+	}
+	if err := verr.Err(); err != nil {
+		log.Error("invalid query", "err", err)
+		writeBadRequest(w, r, &verr)
+		return
+	}
+	var err error
+	if f.Uuid, err = scopeUser(r.Context(), f.Uuid); err != nil {
+		log.Error("forbidden", "err", err)
+		writeError(w, r, err)
+		return
 	}
 
-	out, err := h.Repo.SumByFilter(f)
+	out, err := h.Repo.SumByMonth(r.Context(), f)
 	if err != nil {
-		slog.Error("invalid out", "err", err)
-		http.Error(w, "internal err", http.StatusInternalServerError)
+		log.Error("invalid out", "err", err)
+		writeError(w, r, err)
 		return
 	}
-	w.WriteHeader(http.StatusOK)
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
 	_ = json.NewEncoder(w).Encode(out)
-	slog.Info("ListSum", "out", out)
+	log.Info("ListSumMonthly done", "months", len(out))
+}
+
+// parseListFilter reads the filters and sort shared by List and Export.
+func parseListFilter(q url.Values, verr *domain.ValidationError) domain.ListFilterService {
+	f := domain.ListFilterService{Name: q.Get("name")}
+	f.Uuid = parseUUID(q, "user_id", verr)
+	if s := q.Get("price"); s != "" {
+		price, err := strconv.Atoi(s)
+		if err != nil {
+			verr.Add("price", "must be an integer")
+		}
+		f.Price = price
+	}
+	f.From = parseMonth(q, "from", verr)
+	f.To = parseMonth(q, "to", verr)
+
+	s := strings.ToLower(q.Get("sort"))
+	switch s {
+	case "service_created_at", "service_price", "service_name":
+		f.SortBy = s
+	default:
+		f.SortBy = "service_created_at"
+	}
+	s = strings.ToLower(q.Get("dir"))
+	switch s {
+	case "asc", "desc":
+		f.SortDir = s
+	default:
+		f.SortDir = "desc"
+	}
+	return f
+}
+
+// parseSumFilter ...
+func parseSumFilter(q url.Values, verr *domain.ValidationError) domain.SumFilterService {
+	return domain.SumFilterService{
+		Name: q.Get("name"),
+		Uuid: parseUUID(q, "user_id", verr),
+		From: parseMonth(q, "from", verr),
+		To:   parseMonth(q, "to", verr),
+	}
+}
+
+// parseUUID ...
+func parseUUID(q url.Values, key string, verr *domain.ValidationError) *uuid.UUID {
+	s := q.Get(key)
+	if s == "" {
+		return nil
+	}
+	id, err := uuid.Parse(s)
+	if err != nil {
+		verr.Add(key, "invalid uuid")
+		return nil
+	}
+	return &id
+}
+
+// parseMonth ...
+func parseMonth(q url.Values, key string, verr *domain.ValidationError) *time.Time {
+	s := q.Get(key)
+	if s == "" {
+		return nil
+	}
+	t, err := time.Parse(domain.MonthLayout, s)
+	if err != nil {
+		verr.Add(key, "want MM-YYYY")
+		return nil
+	}
+	return &t
 }
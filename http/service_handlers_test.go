@@ -2,8 +2,10 @@ package http
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"errors"
+	"fmt"
+	"iter"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -11,6 +13,7 @@ import (
 	"time"
 
 	"github.com/animans/REST-API-test-task/domain"
+	"github.com/animans/REST-API-test-task/infastructure"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 )
@@ -51,16 +54,45 @@ type fakeRepo struct {
 }
 
 // SumByFilter implements domain.ServiceRepository.
-func (f *fakeRepo) SumByFilter(domain.SumFilterService) (domain.SumResult, error) {
+func (f *fakeRepo) SumByFilter(context.Context, domain.SumFilterService) (domain.SumResult, error) {
+	panic("unimplemented")
+}
+
+// SumByGroup implements domain.ServiceRepository.
+func (f *fakeRepo) SumByGroup(_ context.Context, s domain.SumFilterService) ([]domain.GroupSum, error) {
+	if s.GroupBy != domain.GroupByName {
+		panic("unimplemented")
+	}
+	return []domain.GroupSum{
+		{Key: "Yandex Plus", Total: 1200, Count: 1},
+		{Key: "GPT Plus", Total: 500, Count: 1},
+	}, nil
+}
+
+// SumByMonth implements domain.ServiceRepository.
+func (f *fakeRepo) SumByMonth(context.Context, domain.SumFilterService) ([]domain.MonthSum, error) {
 	panic("unimplemented")
 }
 
 // ListByFilter implements domain.ServiceRepository.
-func (f *fakeRepo) ListByFilter(domain.ListFilterService) (domain.ListResult, error) {
+func (f *fakeRepo) ListByFilter(context.Context, domain.ListFilterService) (domain.ListResult, error) {
+	panic("unimplemented")
+}
+
+// WithTx implements domain.ServiceRepository.
+func (f *fakeRepo) WithTx(ctx context.Context, fn func(domain.ServiceRepository) error) error {
+	return fn(f)
+}
+
+// IterateByFilter implements domain.ServiceRepository.
+func (f *fakeRepo) IterateByFilter(context.Context, domain.ListFilterService) iter.Seq2[*domain.Service, error] {
 	panic("unimplemented")
 }
 
-func (f *fakeRepo) GetByID(id string) (*domain.Service, error) {
+func (f *fakeRepo) GetByID(_ context.Context, id string) (*domain.Service, error) {
+	if _, err := domain.ParseID(id); err != nil {
+		return &domain.Service{}, err
+	}
 	sdate, err := time.Parse("01-2006", "08-2025")
 	if err != nil {
 		return nil, err
@@ -76,37 +108,38 @@ func (f *fakeRepo) GetByID(id string) (*domain.Service, error) {
 	}
 	fser, ok := fService[id]
 	if !ok {
-		f.saveErr = errors.New("db invalid id")
+		f.saveErr = fmt.Errorf("%w: id=%s", domain.ErrNotFound, id)
 		return &domain.Service{}, f.saveErr
 	}
 	return fser, nil
 }
 
-func (f *fakeRepo) Save(s *domain.Service) (int, error) {
+func (f *fakeRepo) Save(_ context.Context, s *domain.Service) (int, error) {
 	f.saved = s
+	s.SetMeta(1, time.Now(), time.Now())
 	return 1, f.saveErr
 }
 
-func (f *fakeRepo) UpdateByID(id string, s *domain.Service) error {
+func (f *fakeRepo) UpdateByID(_ context.Context, id string, s *domain.Service) error {
 	fService := map[string]*domain.Service{
 		"1": f.saved,
 	}
 	_, ok := fService[id]
 	if !ok {
-		f.saveErr = errors.New("db invalid id")
+		f.saveErr = fmt.Errorf("%w: id=%s", domain.ErrNotFound, id)
 		return f.saveErr
 	}
 	f.saved = s
 	return nil
 }
 
-func (f *fakeRepo) DeleteByID(id string) error {
+func (f *fakeRepo) DeleteByID(_ context.Context, id string) error {
 	fService := map[string]*domain.Service{
 		"1": f.saved,
 	}
 	_, ok := fService[id]
 	if !ok {
-		f.saveErr = errors.New("db invalid id")
+		f.saveErr = fmt.Errorf("%w: id=%s", domain.ErrNotFound, id)
 		return f.saveErr
 	}
 	delete(fService, id)
@@ -116,7 +149,7 @@ func (f *fakeRepo) DeleteByID(id string) error {
 
 func TestCreate(t *testing.T) {
 	frepo := &fakeRepo{}
-	h := NewHandlers(frepo)
+	h := NewHandlers(frepo, "")
 	id := uuid.MustParse("00000000-0000-0000-0000-000000000001")
 
 	load := struct {
@@ -137,6 +170,16 @@ func TestCreate(t *testing.T) {
 	h.Create(rec, req)
 	wantStatus(t, rec, http.StatusCreated)
 	wantBodyContains(t, rec, "1")
+	if loc := rec.Header().Get("Location"); loc != "/service/1" {
+		t.Fatalf("Location: got=%q want=%q", loc, "/service/1")
+	}
+	var got domain.ServiceResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("invalid json: %v; body=%s", err, rec.Body.String())
+	}
+	if got.ID != 1 || got.Name != load.Name || got.StartDate != load.StartDate || got.CreatedAt.IsZero() {
+		t.Fatalf("unexpected body: %#v", got)
+	}
 
 	if frepo.saved == nil {
 		t.Fatalf("repo.Save was not called")
@@ -155,6 +198,22 @@ func TestCreate(t *testing.T) {
 	}
 }
 
+func TestCreateEndDate(t *testing.T) {
+	frepo := &fakeRepo{}
+	h := NewHandlers(frepo, "")
+	body := `{"service_name":"Yandex Plus","price":400,"user_id":"00000000-0000-0000-0000-000000000001","start_date":"07-2025","end_date":"12-2025"}`
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/service", strings.NewReader(body))
+
+	h.Create(rec, req)
+
+	wantStatus(t, rec, http.StatusCreated)
+	ed := frepo.saved.GetEndDate()
+	if ed == nil || ed.Format("01-2006") != "12-2025" {
+		t.Fatalf("saved.EndDate: got=%v want 12-2025", ed)
+	}
+}
+
 func TestGet(t *testing.T) {
 	sdate, err := time.Parse("01-2006", "08-2025")
 	if err != nil {
@@ -232,7 +291,7 @@ func TestPut(t *testing.T) {
 		Uuid:      "00000000-0000-0000-0000-000000000002",
 		StartDate: "05-2025",
 	}
-	h := NewHandlers(frepo)
+	h := NewHandlers(frepo, "")
 	rec := httptest.NewRecorder()
 	req := httptest.NewRequest(http.MethodPut, "/service/1", mustJSON(t, load))
 	req = mux.SetURLVars(req, map[string]string{"id": "1"})
@@ -266,7 +325,7 @@ func TestDelete(t *testing.T) {
 			sdate,
 		),
 	}
-	h := NewHandlers(frepoo)
+	h := NewHandlers(frepoo, "")
 	rec := httptest.NewRecorder()
 	req := httptest.NewRequest(http.MethodDelete, "/service/1", nil)
 	req = mux.SetURLVars(req, map[string]string{"id": "1"})
@@ -279,6 +338,208 @@ func TestDelete(t *testing.T) {
 	}
 }
 
+func TestErrorStatus(t *testing.T) {
+	cases := []struct {
+		name   string
+		method string
+		id     string
+		body   string
+		want   int
+	}{
+		{"get_not_found", http.MethodGet, "2", "", http.StatusNotFound},
+		{"get_bad_id", http.MethodGet, "abc", "", http.StatusBadRequest},
+		{"put_not_found", http.MethodPut, "2", `{"service_name":"A","price":1,"user_id":"00000000-0000-0000-0000-000000000001","start_date":"01-2025"}`, http.StatusNotFound},
+		{"put_invalid", http.MethodPut, "1", `{"service_name":" ","price":-1,"user_id":"x","start_date":"2025-01"}`, http.StatusUnprocessableEntity},
+		{"put_end_before_start", http.MethodPut, "1", `{"service_name":"A","price":1,"user_id":"00000000-0000-0000-0000-000000000001","start_date":"05-2025","end_date":"04-2025"}`, http.StatusUnprocessableEntity},
+		{"put_bad_json", http.MethodPut, "1", `{bad}`, http.StatusBadRequest},
+		{"delete_not_found", http.MethodDelete, "2", "", http.StatusNotFound},
+		{"create_invalid", http.MethodPost, "", `{"service_name":"","price":100,"user_id":"00000000-0000-0000-0000-000000000001","start_date":"01-2025"}`, http.StatusUnprocessableEntity},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			h := NewHandlers(&fakeRepo{}, "")
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(c.method, "/service/"+c.id, strings.NewReader(c.body))
+			req = mux.SetURLVars(req, map[string]string{"id": c.id})
+			switch c.method {
+			case http.MethodGet:
+				h.Get(rec, req)
+			case http.MethodPut:
+				h.Put(rec, req)
+			case http.MethodDelete:
+				h.Delete(rec, req)
+			case http.MethodPost:
+				h.Create(rec, req)
+			}
+			wantStatus(t, rec, c.want)
+		})
+	}
+}
+
+func TestProblemJSON(t *testing.T) {
+	h := NewHandlers(&fakeRepo{}, "")
+	rec := httptest.NewRecorder()
+	body := `{"service_name":"","price":-5,"user_id":"00000000-0000-0000-0000-000000000001","start_date":"13-2025"}`
+	req := httptest.NewRequest(http.MethodPost, "/service", strings.NewReader(body))
+
+	h.Create(rec, req)
+
+	wantStatus(t, rec, http.StatusUnprocessableEntity)
+	if ct := rec.Header().Get("Content-Type"); ct != ProblemContentType {
+		t.Fatalf("content-type: got=%q want=%q", ct, ProblemContentType)
+	}
+	var p Problem
+	if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+		t.Fatalf("invalid json: %v; body=%s", err, rec.Body.String())
+	}
+	if p.Status != http.StatusUnprocessableEntity || p.Instance != "/service" || p.Type == "" {
+		t.Fatalf("unexpected problem: %#v", p)
+	}
+	fields := map[string]bool{}
+	for _, e := range p.Errors {
+		fields[e.Field] = true
+	}
+	for _, f := range []string{"service_name", "price", "start_date"} {
+		if !fields[f] {
+			t.Errorf("missing field error %q in %#v", f, p.Errors)
+		}
+	}
+}
+
+func TestProblemQuery(t *testing.T) {
+	h := NewHandlers(&fakeRepo{}, "")
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/service/summary?user_id=bad&from=2024-01", nil)
+
+	h.ListSum(rec, req)
+
+	wantStatus(t, rec, http.StatusBadRequest)
+	wantBodyContains(t, rec, `"field":"user_id"`)
+	wantBodyContains(t, rec, `"field":"from"`)
+}
+
+func TestListCursor(t *testing.T) {
+	h := NewHandlers(&fakeRepo{}, "")
+	tok := h.Cursors.Encode(domain.Cursor{SortBy: "service_price", SortDir: "asc", Value: "400", ID: 1})
+	for _, q := range []string{
+		"cursor=" + tok + "x&sort=service_price&dir=asc",
+		"cursor=" + tok + "&sort=service_name&dir=asc",
+		"cursor=" + NewHandlers(&fakeRepo{}, "").Cursors.Encode(domain.Cursor{SortBy: "service_price", SortDir: "asc"}) + "&sort=service_price&dir=asc",
+	} {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/service?"+q, nil)
+
+		h.List(rec, req)
+
+		wantStatus(t, rec, http.StatusBadRequest)
+		wantBodyContains(t, rec, `"field":"cursor"`)
+	}
+}
+
+func TestListPages(t *testing.T) {
+	repo := infastructure.NewServiceRepoMem()
+	for i := 1; i <= 5; i++ {
+		s := domain.NewService("Service", i*100, uuid.New(), time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC))
+		if _, err := repo.Save(t.Context(), s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h := NewHandlers(repo, "")
+
+	var prices []int
+	var res domain.ListResult
+	query := "sort=service_price&dir=asc&limit=2&total=true"
+	for page := 0; page < 3; page++ {
+		rec := httptest.NewRecorder()
+		h.List(rec, httptest.NewRequest(http.MethodGet, "/service?"+query, nil))
+		wantStatus(t, rec, http.StatusOK)
+		res = domain.ListResult{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+			t.Fatalf("invalid json: %v; body=%s", err, rec.Body.String())
+		}
+		if res.Total == nil || *res.Total != 5 {
+			t.Fatalf("total: got=%v want 5", res.Total)
+		}
+		for _, it := range res.Items {
+			prices = append(prices, it.Price)
+		}
+		query = "sort=service_price&dir=asc&limit=2&total=true&cursor=" + res.NextCursor
+	}
+	if fmt.Sprint(prices) != "[100 200 300 400 500]" || res.NextCursor != "" || res.PrevCursor == "" {
+		t.Fatalf("pages: prices=%v next=%q prev=%q", prices, res.NextCursor, res.PrevCursor)
+	}
+
+	rec := httptest.NewRecorder()
+	h.List(rec, httptest.NewRequest(http.MethodGet, "/service?sort=service_price&dir=asc&limit=2&cursor="+res.PrevCursor, nil))
+	wantStatus(t, rec, http.StatusOK)
+	wantBodyContains(t, rec, `"price":300`)
+	wantBodyContains(t, rec, `"price":400`)
+}
+
+func TestContextErrors(t *testing.T) {
+	h := NewHandlers(infastructure.NewServiceRepoMem(), "")
+
+	ctx, cancel := context.WithTimeout(context.Background(), -time.Second)
+	defer cancel()
+	rec := httptest.NewRecorder()
+	h.List(rec, httptest.NewRequest(http.MethodGet, "/service", nil).WithContext(ctx))
+	wantStatus(t, rec, http.StatusGatewayTimeout)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	rec = httptest.NewRecorder()
+	h.ListSum(rec, httptest.NewRequest(http.MethodGet, "/service/summary", nil).WithContext(ctx))
+	wantStatus(t, rec, StatusClientClosedRequest)
+}
+
+func TestListSumGrouped(t *testing.T) {
+	h := NewHandlers(&fakeRepo{}, "")
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/service/summary?group_by=service_name", nil)
+
+	h.ListSum(rec, req)
+
+	wantStatus(t, rec, http.StatusOK)
+	var got domain.SumResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("invalid json: %v; body=%s", err, rec.Body.String())
+	}
+	if got.Total != 1700 || len(got.Groups) != 2 || got.Groups[0].Key != "Yandex Plus" {
+		t.Fatalf("unexpected result: %#v", got)
+	}
+
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/service/summary?group_by=price", nil)
+	h.ListSum(rec, req)
+	wantStatus(t, rec, http.StatusBadRequest)
+	wantBodyContains(t, rec, `"field":"group_by"`)
+}
+
+func TestListSumMonthlyQuery(t *testing.T) {
+	cases := []struct {
+		name  string
+		query string
+		field string
+	}{
+		{"missing_from", "to=03-2024", "from"},
+		{"missing_to", "from=03-2024", "to"},
+		{"to_before_from", "from=03-2024&to=01-2024", "to"},
+		{"too_wide", "from=01-2000&to=01-2024", "to"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			h := NewHandlers(&fakeRepo{}, "")
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/service/summary/monthly?"+c.query, nil)
+
+			h.ListSumMonthly(rec, req)
+
+			wantStatus(t, rec, http.StatusBadRequest)
+			wantBodyContains(t, rec, `"field":"`+c.field+`"`)
+		})
+	}
+}
+
 // func TestCreate(t *testing.T) {
 // 	casetest := []struct {
 // 		name       string
@@ -0,0 +1,49 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName ...
+const tracerName = "github.com/animans/REST-API-test-task/http"
+
+// Tracing starts a server span per request named after the mux route template,
+// continuing the W3C trace context sent by the client.
+func Tracing(tp trace.TracerProvider) mux.MiddlewareFunc {
+	tracer := tp.Tracer(tracerName)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := routeTemplate(r)
+			ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+			ctx, span := tracer.Start(ctx, r.Method+" "+route,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					semconv.HTTPRequestMethodKey.String(r.Method),
+					semconv.HTTPRoute(route),
+					semconv.URLPath(r.URL.Path),
+					semconv.ClientAddress(r.RemoteAddr),
+				),
+			)
+			defer span.End()
+
+			sw := newStatusWriter(w)
+			aborted := serve(next, sw, r.WithContext(ctx))
+
+			span.SetAttributes(semconv.HTTPResponseStatusCode(sw.status))
+			switch {
+			case aborted:
+				span.SetStatus(codes.Error, "response aborted")
+				panic(http.ErrAbortHandler)
+			case sw.status >= http.StatusInternalServerError:
+				span.SetStatus(codes.Error, http.StatusText(sw.status))
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/animans/REST-API-test-task/infastructure"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracingMiddleware(t *testing.T) {
+	prev := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(prev) })
+
+	sr := tracetest.NewSpanRecorder()
+	h := NewHandlers(infastructure.NewServiceRepoMem(), "")
+	h.TracerProvider = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+
+	req := httptest.NewRequest(http.MethodGet, "/service/5", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	h.Router().ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := sr.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("spans: got %d, want 1", len(spans))
+	}
+	s := spans[0]
+	if s.Name() != "GET /service/{id}" || s.SpanKind() != trace.SpanKindServer {
+		t.Fatalf("span: got %q kind %v", s.Name(), s.SpanKind())
+	}
+	if got := s.Parent().TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" || !s.Parent().IsRemote() {
+		t.Fatalf("parent trace id: got %s", got)
+	}
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, kv := range s.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	if attrs["http.route"].AsString() != "/service/{id}" || attrs["http.response.status_code"].AsInt64() != http.StatusNotFound {
+		t.Fatalf("attributes: %v", s.Attributes())
+	}
+}
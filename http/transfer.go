@@ -0,0 +1,237 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/animans/REST-API-test-task/domain"
+	"github.com/animans/REST-API-test-task/logging"
+	"github.com/animans/REST-API-test-task/transfer"
+)
+
+// DefaultMaxImportBytes ...
+const DefaultMaxImportBytes = 10 << 20
+
+// DefaultExportTimeout ...
+const DefaultExportTimeout = 10 * time.Minute
+
+// importFormats maps the accepted request media types to transfer formats.
+var importFormats = map[string]string{
+	"application/x-ndjson": transfer.FormatNDJSON,
+	"application/ndjson":   transfer.FormatNDJSON,
+	"application/jsonl":    transfer.FormatNDJSON,
+	"text/csv":             transfer.FormatCSV,
+}
+
+// Import
+// @Summary      Import services
+// @Description  Массовая загрузка подписок из JSON lines или CSV (заголовок service_name,price,user_id,start_date,end_date).
+// @Description  Каждая строка проверяется как в POST /service; валидные строки сохраняются пачками в транзакции, отчёт содержит id или ошибки по номерам строк.
+// @Tags         service
+// @Accept       application/x-ndjson
+// @Accept       text/csv
+// @Produce      json
+// @Param        dry_run query bool   false "validate without saving"
+// @Param        input   body  string true  "one service per line"
+// @Success      200   {object} transfer.Report
+// @Failure      400   {object} Problem
+// @Failure      401   {object} Problem
+// @Failure      403   {object} Problem
+// @Failure      413   {object} Problem
+// @Failure      415   {object} Problem
+// @Failure      429   {object} Problem
+// @Failure      500   {object} Problem
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Router       /service/import [post]
+func (h *Handlers) Import(w http.ResponseWriter, r *http.Request) {
+	log := logging.FromContext(r.Context())
+	log.Info("Import start")
+	mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	format, ok := importFormats[mt]
+	if err != nil || !ok {
+		log.Error("unsupported media type", "content_type", r.Header.Get("Content-Type"))
+		writeProblem(w, r, http.StatusUnsupportedMediaType, "expected application/x-ndjson or text/csv")
+		return
+	}
+	var (
+		verr   domain.ValidationError
+		dryRun bool
+	)
+	if s := r.URL.Query().Get("dry_run"); s != "" {
+		if dryRun, err = strconv.ParseBool(s); err != nil {
+			verr.Add("dry_run", "must be a boolean")
+			writeBadRequest(w, r, &verr)
+			return
+		}
+	}
+
+	// Read the whole body first so an oversized request is refused before anything is saved.
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, h.maxImportBytes()))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		log.Error("request too large", "limit", tooLarge.Limit)
+		writeProblem(w, r, http.StatusRequestEntityTooLarge, "request body exceeds "+strconv.FormatInt(tooLarge.Limit, 10)+" bytes")
+		return
+	}
+	if err != nil {
+		log.Error("read body error", "err", err)
+		writeProblem(w, r, http.StatusBadRequest, "cannot read request body")
+		return
+	}
+	ctx := r.Context()
+	run := func(dryRun bool) (transfer.Report, error) {
+		rd, err := transfer.NewReader(bytes.NewReader(body), format)
+		if err != nil {
+			return transfer.Report{}, fmt.Errorf("%w: %v", transfer.ErrMalformed, err)
+		}
+		return transfer.Import(ctx, h.Repo, rd, transfer.ImportOptions{
+			DryRun: dryRun,
+			Check:  func(s *domain.Service) error { return checkOwner(ctx, s) },
+		})
+	}
+	// Validate everything first so malformed input is refused before anything is saved.
+	rep, err := run(true)
+	if err == nil && !dryRun {
+		rep, err = run(false)
+	}
+	if errors.Is(err, transfer.ErrMalformed) {
+		log.Error("invalid input", "err", err)
+		writeProblem(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		log.Error("import error", "err", err, "imported", rep.Imported)
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(rep)
+	log.Info("Import done", "total", rep.Total, "imported", rep.Imported, "failed", rep.Failed, "dry_run", rep.DryRun)
+}
+
+// Export
+// @Summary      Export services
+// @Description  Выгрузка всех подписок по тем же фильтрам, что и GET /service, без ограничения limit.
+// @Description  Даты в формате MM-YYYY, как в API; файл отдаётся как вложение.
+// @Tags         service
+// @Produce      text/csv
+// @Produce      application/x-ndjson
+// @Produce      application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param        format  query string false "csv (default), ndjson or xlsx" Enums(csv, ndjson, xlsx)
+// @Param        name    query string false "name filter (ILIKE)"
+// @Param        user_id query string false "user uuid" format(uuid)
+// @Param        price   query integer false "exact price"
+// @Param        from    query string false "active from month (MM-YYYY)" example(01-2024)
+// @Param        to      query string false "active to month (MM-YYYY)" example(03-2024)
+// @Param        sort    query string false "sort by (service_created_at, service_price, service_name)"
+// @Param        dir     query string false "order by (asc, desc)"
+// @Success      200 {file} file
+// @Header       200 {string} Content-Disposition "attachment; filename=services-YYYYMMDD.csv"
+// @Failure      400 {object} Problem
+// @Failure      401 {object} Problem
+// @Failure      403 {object} Problem
+// @Failure      429 {object} Problem
+// @Failure      500 {object} Problem
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Router       /service/export [get]
+func (h *Handlers) Export(w http.ResponseWriter, r *http.Request) {
+	log := logging.FromContext(r.Context())
+	log.Info("Export start", "r.URL.Query()", r.URL.Query())
+	q := r.URL.Query()
+	var verr domain.ValidationError
+	f := parseListFilter(q, &verr)
+	format := transfer.FormatCSV
+	if s := q.Get("format"); s != "" {
+		var err error
+		if format, err = transfer.ParseFormat(s); err != nil {
+			verr.Add("format", "must be csv, ndjson or xlsx")
+		}
+	}
+	if err := verr.Err(); err != nil {
+		log.Error("invalid query", "err", err)
+		writeBadRequest(w, r, &verr)
+		return
+	}
+	var err error
+	if f.Uuid, err = scopeUser(r.Context(), f.Uuid); err != nil {
+		log.Error("forbidden", "err", err)
+		writeError(w, r, err)
+		return
+	}
+
+	// A full export easily outlives the server WriteTimeout meant for ordinary requests.
+	var deadline time.Time
+	if h.ExportTimeout > 0 {
+		deadline = time.Now().Add(h.ExportTimeout)
+	}
+	if err := http.NewResponseController(w).SetWriteDeadline(deadline); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		log.Warn("set write deadline error", "err", err)
+	}
+
+	name := "services-" + time.Now().UTC().Format("20060102") + "." + format
+	out := &attachment{ResponseWriter: w, contentType: transfer.ContentType(format), filename: name}
+	tw, err := transfer.NewWriter(out, format)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	n, err := transfer.Export(r.Context(), h.Repo, f, tw)
+	if err != nil && !out.started {
+		log.Error("export error", "err", err)
+		writeError(w, r, err)
+		return
+	}
+	if err != nil {
+		// The status is already sent, cut the connection so the file is visibly truncated.
+		log.Error("export aborted", "err", err, "rows", n)
+		panic(http.ErrAbortHandler)
+	}
+	out.start()
+	log.Info("Export done", "rows", n, "format", format)
+}
+
+// attachment sends the download headers with the first body write, so errors
+// before any output still get a problem response.
+type attachment struct {
+	http.ResponseWriter
+	contentType string
+	filename    string
+	started     bool
+}
+
+// start sends the headers once; an empty export still needs them.
+func (a *attachment) start() {
+	if a.started {
+		return
+	}
+	a.started = true
+	h := a.Header()
+	h.Set("Content-Type", a.contentType)
+	h.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": a.filename}))
+	h.Set("X-Content-Type-Options", "nosniff")
+	a.WriteHeader(http.StatusOK)
+}
+
+func (a *attachment) Write(b []byte) (int, error) {
+	a.start()
+	return a.ResponseWriter.Write(b)
+}
+
+// maxImportBytes ...
+func (h *Handlers) maxImportBytes() int64 {
+	if h.MaxImportBytes > 0 {
+		return h.MaxImportBytes
+	}
+	return DefaultMaxImportBytes
+}
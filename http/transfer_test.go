@@ -0,0 +1,232 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"iter"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/animans/REST-API-test-task/domain"
+	"github.com/animans/REST-API-test-task/infastructure"
+	"github.com/animans/REST-API-test-task/transfer"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestImport(t *testing.T) {
+	repo := infastructure.NewServiceRepoMem()
+	h := NewHandlers(repo, "")
+	h.MaxImportBytes = 1024
+	router := h.Router()
+	do := func(target, contentType, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	count := func() int {
+		t.Helper()
+		res, err := repo.ListByFilter(t.Context(), domain.ListFilterService{Limit: 100, WithTotal: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return *res.Total
+	}
+
+	user := uuid.NewString()
+	ndjson := `{"service_name":"Netflix","price":100,"user_id":"` + user + `","start_date":"01-2025"}
+{"service_name":"","price":100,"user_id":"` + user + `","start_date":"01-2025"}
+{"service_name":"Okko","price":200,"user_id":"` + user + `","start_date":"02-2025","end_date":"03-2025"}
+`
+	rec := do("/service/import?dry_run=true", "application/x-ndjson", ndjson)
+	wantStatus(t, rec, http.StatusOK)
+	var rep transfer.Report
+	if err := json.Unmarshal(rec.Body.Bytes(), &rep); err != nil || !rep.DryRun || rep.Valid != 2 || rep.Imported != 0 || rep.Failed != 1 {
+		t.Fatalf("dry run: %+v, %v", rep, err)
+	}
+	if n := count(); n != 0 {
+		t.Fatalf("dry run saved %d services", n)
+	}
+
+	rec = do("/service/import", "application/x-ndjson; charset=utf-8", ndjson)
+	wantStatus(t, rec, http.StatusOK)
+	rep = transfer.Report{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &rep); err != nil || rep.Imported != 2 || rep.Failed != 1 || len(rep.Lines) != 3 {
+		t.Fatalf("import: %+v, %v", rep, err)
+	}
+	if rep.Lines[0].ID == 0 || rep.Lines[1].Error == "" || len(rep.Lines[1].Fields) != 1 || rep.Lines[2].ID == 0 {
+		t.Fatalf("lines: %+v", rep.Lines)
+	}
+	if n := count(); n != 2 {
+		t.Fatalf("saved %d services, want 2", n)
+	}
+
+	rec = do("/service/import", "text/csv", "service_name,price,user_id,start_date\nSpotify,300,"+user+",05-2024\n")
+	wantStatus(t, rec, http.StatusOK)
+	wantBodyContains(t, rec, `"imported":1`)
+
+	wantStatus(t, do("/service/import", "application/json", ndjson), http.StatusUnsupportedMediaType)
+	wantStatus(t, do("/service/import?dry_run=maybe", "text/csv", ""), http.StatusBadRequest)
+	wantStatus(t, do("/service/import", "text/csv", "price\n1\n"), http.StatusBadRequest)
+	// A broken quote stops the reader; nothing before it may be saved.
+	rec = do("/service/import", "text/csv", "service_name,price,user_id,start_date\nOkko,1,"+user+",01-2025\n\"Okko,1\n")
+	wantStatus(t, rec, http.StatusBadRequest)
+	if n := count(); n != 3 {
+		t.Fatalf("malformed input saved services: %d", n)
+	}
+	rec = do("/service/import", "application/x-ndjson", strings.Repeat(" ", 2048))
+	wantStatus(t, rec, http.StatusRequestEntityTooLarge)
+	wantBodyContains(t, rec, "/problems/too-large")
+}
+
+func TestExport(t *testing.T) {
+	repo := infastructure.NewServiceRepoMem()
+	alice, bob := uuid.New(), uuid.New()
+	for i, u := range []uuid.UUID{alice, alice, bob} {
+		s := domain.NewService("Netflix", 100*(i+1), u, time.Date(2025, time.Month(i+1), 1, 0, 0, 0, 0, time.UTC))
+		if _, err := repo.Save(t.Context(), s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h := NewHandlers(repo, "")
+	router := h.Router()
+	get := func(target string) *httptest.ResponseRecorder {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+
+	rec := get("/service/export?user_id=" + alice.String() + "&dir=asc")
+	wantStatus(t, rec, http.StatusOK)
+	if ct, cd := rec.Header().Get("Content-Type"), rec.Header().Get("Content-Disposition"); ct != "text/csv; charset=utf-8" || !strings.HasPrefix(cd, "attachment; filename=services-") || !strings.HasSuffix(cd, ".csv") {
+		t.Fatalf("headers: %q, %q", ct, cd)
+	}
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	if len(lines) != 3 || lines[0] != strings.Join(transfer.Columns, ",") || !strings.Contains(lines[1], ",01-2025,") || !strings.Contains(lines[2], ",02-2025,") {
+		t.Fatalf("csv: %q", lines)
+	}
+
+	rec = get("/service/export?format=ndjson&sort=service_price&dir=desc")
+	wantStatus(t, rec, http.StatusOK)
+	var first domain.ServiceResponse
+	if err := json.NewDecoder(rec.Body).Decode(&first); err != nil || first.Price != 300 || first.StartDate != "03-2025" {
+		t.Fatalf("ndjson: %+v, %v", first, err)
+	}
+
+	rec = get("/service/export?format=xlsx&name=nothing")
+	wantStatus(t, rec, http.StatusOK)
+	if !strings.HasSuffix(rec.Header().Get("Content-Disposition"), ".xlsx") || !bytes.HasPrefix(rec.Body.Bytes(), []byte("PK")) {
+		t.Fatalf("xlsx: %q", rec.Header())
+	}
+	rec = get("/service/export?format=ndjson&name=nothing")
+	wantStatus(t, rec, http.StatusOK)
+	wantBodyEmpty(t, rec)
+	if rec.Header().Get("Content-Disposition") == "" {
+		t.Fatal("empty export without Content-Disposition")
+	}
+
+	wantStatus(t, get("/service/export?format=pdf"), http.StatusBadRequest)
+	wantStatus(t, get("/service/export?from=2025-01"), http.StatusBadRequest)
+}
+
+func TestExportWriteDeadline(t *testing.T) {
+	repo := infastructure.NewServiceRepoMem()
+	for i := range 3 {
+		if _, err := repo.Save(t.Context(), domain.NewService("Netflix", 100*(i+1), uuid.New(), time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h := NewHandlers(slowRepo{repo, 200 * time.Millisecond}, "")
+	srv := httptest.NewUnstartedServer(h.Router())
+	srv.Config.WriteTimeout = 50 * time.Millisecond
+	srv.Start()
+	defer srv.Close()
+
+	res, err := srv.Client().Get(srv.URL + "/service/export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatalf("export cut off by the server write timeout: %v", err)
+	}
+	if n := strings.Count(string(body), "\n"); res.StatusCode != http.StatusOK || n != 4 {
+		t.Fatalf("status %d, %d lines", res.StatusCode, n)
+	}
+}
+
+// slowRepo delays every exported row.
+type slowRepo struct {
+	domain.ServiceRepository
+	delay time.Duration
+}
+
+func (r slowRepo) IterateByFilter(ctx context.Context, f domain.ListFilterService) iter.Seq2[*domain.Service, error] {
+	return func(yield func(*domain.Service, error) bool) {
+		for s, err := range r.ServiceRepository.IterateByFilter(ctx, f) {
+			time.Sleep(r.delay)
+			if !yield(s, err) {
+				return
+			}
+		}
+	}
+}
+
+func TestExportAborted(t *testing.T) {
+	repo := infastructure.NewServiceRepoMem()
+	for i := range 100 {
+		if _, err := repo.Save(t.Context(), domain.NewService("Netflix", i+1, uuid.New(), time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var buf bytes.Buffer
+	h := NewHandlers(brokenRepo{repo}, "")
+	h.Logger = slog.New(slog.NewJSONHandler(&buf, nil))
+	h.Metrics = NewMetrics(prometheus.NewRegistry())
+	router := h.Router()
+
+	func() {
+		defer func() {
+			if v := recover(); v != http.ErrAbortHandler {
+				t.Fatalf("recovered %v, want http.ErrAbortHandler", v)
+			}
+		}()
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/service/export?format=ndjson", nil))
+	}()
+
+	lines := logLines(t, &buf)
+	if access := lines[len(lines)-1]; access["msg"] != "access" || access["level"] != "ERROR" || access["aborted"] != true {
+		t.Fatalf("access line: %v", access)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	wantBodyContains(t, rec, `http_requests_total{method="GET",route="/service/export",status="aborted"} 1`)
+}
+
+// brokenRepo fails every export after the last row.
+type brokenRepo struct {
+	domain.ServiceRepository
+}
+
+func (r brokenRepo) IterateByFilter(ctx context.Context, f domain.ListFilterService) iter.Seq2[*domain.Service, error] {
+	return func(yield func(*domain.Service, error) bool) {
+		for s, err := range r.ServiceRepository.IterateByFilter(ctx, f) {
+			if !yield(s, err) {
+				return
+			}
+		}
+		yield(nil, errors.New("connection reset"))
+	}
+}
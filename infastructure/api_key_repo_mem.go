@@ -0,0 +1,120 @@
+package infastructure
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/animans/REST-API-test-task/domain"
+	"github.com/google/uuid"
+)
+
+// APIKeyRepoMem is an in-memory APIKeyRepository with the same semantics as APIKeyRepoPG.
+type APIKeyRepoMem struct {
+	mu   sync.RWMutex
+	keys []*domain.APIKey
+	now  func() time.Time
+}
+
+// NewAPIKeyRepoMem ...
+func NewAPIKeyRepoMem() *APIKeyRepoMem {
+	return &APIKeyRepoMem{now: time.Now}
+}
+
+// cloneKey copies k so callers never share the stored pointers.
+func cloneKey(k *domain.APIKey) *domain.APIKey {
+	c := *k
+	c.Scopes = slices.Clone(k.Scopes)
+	return &c
+}
+
+// Create ...
+func (r *APIKeyRepoMem) Create(ctx context.Context, k *domain.APIKey) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, old := range r.keys {
+		if old.Hash == k.Hash {
+			return fmt.Errorf("%w: duplicate api key", domain.ErrConflict)
+		}
+	}
+	k.ID = uuid.New()
+	k.CreatedAt = r.now().UTC()
+	r.keys = append(r.keys, cloneKey(k))
+	return nil
+}
+
+// GetByHash ...
+func (r *APIKeyRepoMem) GetByHash(ctx context.Context, hash string) (*domain.APIKey, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, k := range r.keys {
+		if k.Hash == hash {
+			return cloneKey(k), nil
+		}
+	}
+	return nil, domain.ErrAPIKeyNotFound
+}
+
+// List ...
+func (r *APIKeyRepoMem) List(ctx context.Context) ([]domain.APIKey, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]domain.APIKey, 0, len(r.keys))
+	for _, k := range r.keys {
+		out = append(out, *cloneKey(k))
+	}
+	return out, nil
+}
+
+// find ...
+func (r *APIKeyRepoMem) find(id uuid.UUID) *domain.APIKey {
+	for _, k := range r.keys {
+		if k.ID == id {
+			return k
+		}
+	}
+	return nil
+}
+
+// Revoke ...
+func (r *APIKeyRepoMem) Revoke(ctx context.Context, id uuid.UUID) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	k := r.find(id)
+	if k == nil {
+		return fmt.Errorf("%w: id=%s", domain.ErrAPIKeyNotFound, id)
+	}
+	if k.RevokedAt == nil {
+		now := r.now().UTC()
+		k.RevokedAt = &now
+	}
+	return nil
+}
+
+// Touch ...
+func (r *APIKeyRepoMem) Touch(ctx context.Context, id uuid.UUID, at time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if k := r.find(id); k != nil && (k.LastUsedAt == nil || k.LastUsedAt.Before(at)) {
+		at = at.UTC()
+		k.LastUsedAt = &at
+	}
+	return nil
+}
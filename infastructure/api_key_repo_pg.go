@@ -0,0 +1,173 @@
+package infastructure
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/animans/REST-API-test-task/domain"
+	"github.com/animans/REST-API-test-task/logging"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// APIKeyRepoPG stores API keys in the api_keys table.
+type APIKeyRepoPG struct {
+	db *sql.DB
+	// QueryTimeout bounds every repository call, zero disables it.
+	QueryTimeout time.Duration
+	// Tracer records a span per repository method, the global provider by default.
+	Tracer trace.Tracer
+}
+
+// NewAPIKeyRepoPG shares the pool of an opened ServiceRepoPG.
+func NewAPIKeyRepoPG(db *sql.DB) *APIKeyRepoPG {
+	return &APIKeyRepoPG{
+		db:           db,
+		QueryTimeout: DefaultQueryTimeout,
+		Tracer:       otel.Tracer("github.com/animans/REST-API-test-task/infastructure"),
+	}
+}
+
+// start applies the query timeout and starts a client span for a repository method.
+func (r *APIKeyRepoPG) start(ctx context.Context, method string) (context.Context, func()) {
+	var cancel context.CancelFunc
+	if r.QueryTimeout <= 0 {
+		ctx, cancel = context.WithCancel(ctx)
+	} else {
+		ctx, cancel = context.WithTimeout(ctx, r.QueryTimeout)
+	}
+	ctx, span := r.Tracer.Start(ctx, "APIKeyRepoPG."+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemNamePostgreSQL, semconv.DBOperationName(method)),
+	)
+	return ctx, func() {
+		span.End()
+		cancel()
+	}
+}
+
+// apiKeyColumns lists the columns scanned by scanAPIKey.
+const apiKeyColumns = "key_id, key_name, key_prefix, key_hash, key_user_uuid, key_scopes, key_expires_at, key_last_used_at, key_revoked_at, key_created_at"
+
+// scanAPIKey ...
+func scanAPIKey(row interface{ Scan(...any) error }) (*domain.APIKey, error) {
+	var (
+		k    domain.APIKey
+		user uuid.NullUUID
+	)
+	if err := row.Scan(&k.ID, &k.Name, &k.Prefix, &k.Hash, &user, pq.Array(&k.Scopes), &k.ExpiresAt, &k.LastUsedAt, &k.RevokedAt, &k.CreatedAt); err != nil {
+		return nil, err
+	}
+	if user.Valid {
+		k.UserID = &user.UUID
+	}
+	return &k, nil
+}
+
+// Create ...
+func (r *APIKeyRepoPG) Create(ctx context.Context, k *domain.APIKey) error {
+	log := logging.FromContext(ctx)
+	ctx, done := r.start(ctx, "Create")
+	defer done()
+
+	k.ID = uuid.New()
+	if err := r.db.QueryRowContext(ctx,
+		statement(ctx, "INSERT INTO api_keys (key_id, key_name, key_prefix, key_hash, key_user_uuid, key_scopes, key_expires_at) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING key_created_at"),
+		k.ID, k.Name, k.Prefix, k.Hash, k.UserID, pq.Array(k.Scopes), k.ExpiresAt,
+	).Scan(&k.CreatedAt); err != nil {
+		log.Error("Create Query error", "err", err)
+		return mapPQError(ctx, err)
+	}
+
+	log.Debug("Create done", "id", k.ID)
+	return nil
+}
+
+// GetByHash ...
+func (r *APIKeyRepoPG) GetByHash(ctx context.Context, hash string) (*domain.APIKey, error) {
+	log := logging.FromContext(ctx)
+	ctx, done := r.start(ctx, "GetByHash")
+	defer done()
+
+	k, err := scanAPIKey(r.db.QueryRowContext(ctx, statement(ctx, "SELECT "+apiKeyColumns+" FROM api_keys WHERE key_hash=$1"), hash))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrAPIKeyNotFound
+	}
+	if err != nil {
+		log.Error("GetByHash Query error", "err", err)
+		return nil, mapPQError(ctx, err)
+	}
+	return k, nil
+}
+
+// List ...
+func (r *APIKeyRepoPG) List(ctx context.Context) ([]domain.APIKey, error) {
+	log := logging.FromContext(ctx)
+	ctx, done := r.start(ctx, "List")
+	defer done()
+
+	rows, err := r.db.QueryContext(ctx, statement(ctx, "SELECT "+apiKeyColumns+" FROM api_keys ORDER BY key_created_at, key_id"))
+	if err != nil {
+		log.Error("List Query error", "err", err)
+		return nil, mapPQError(ctx, err)
+	}
+	defer rows.Close()
+
+	out := []domain.APIKey{}
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			log.Error("List Scan error", "err", err)
+			return nil, mapPQError(ctx, err)
+		}
+		out = append(out, *k)
+	}
+	if err := rows.Err(); err != nil {
+		log.Error("List rows error", "err", err)
+		return nil, mapPQError(ctx, err)
+	}
+	return out, nil
+}
+
+// Revoke ...
+func (r *APIKeyRepoPG) Revoke(ctx context.Context, id uuid.UUID) error {
+	log := logging.FromContext(ctx)
+	ctx, done := r.start(ctx, "Revoke")
+	defer done()
+
+	res, err := r.db.ExecContext(ctx, statement(ctx, "UPDATE api_keys SET key_revoked_at=COALESCE(key_revoked_at, now()) WHERE key_id=$1"), id)
+	if err != nil {
+		log.Error("Revoke Exec error", "err", err)
+		return mapPQError(ctx, err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return fmt.Errorf("%w: id=%s", domain.ErrAPIKeyNotFound, id)
+	}
+
+	log.Debug("Revoke done", "id", id)
+	return nil
+}
+
+// Touch moves last_used_at forward, never back.
+func (r *APIKeyRepoPG) Touch(ctx context.Context, id uuid.UUID, at time.Time) error {
+	log := logging.FromContext(ctx)
+	ctx, done := r.start(ctx, "Touch")
+	defer done()
+
+	if _, err := r.db.ExecContext(ctx,
+		statement(ctx, "UPDATE api_keys SET key_last_used_at=$2 WHERE key_id=$1 AND (key_last_used_at IS NULL OR key_last_used_at < $2)"),
+		id, at,
+	); err != nil {
+		log.Error("Touch Exec error", "err", err)
+		return mapPQError(ctx, err)
+	}
+	return nil
+}
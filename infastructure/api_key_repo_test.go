@@ -0,0 +1,86 @@
+package infastructure
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/animans/REST-API-test-task/domain"
+	"github.com/google/uuid"
+)
+
+func TestAPIKeyRepoMem(t *testing.T) {
+	testAPIKeyRepo(t, NewAPIKeyRepoMem())
+}
+
+// TestAPIKeyRepoPG truncates api_keys in DATABASE_URL, so never point it at real data.
+func TestAPIKeyRepoPG(t *testing.T) {
+	if _, ok := os.LookupEnv("DATABASE_URL"); !ok {
+		t.Skip("DATABASE_URL not set")
+	}
+	s := NewServiceRepoPG(os.Getenv("DATABASE_URL"))
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	if _, err := s.db.Exec("TRUNCATE api_keys"); err != nil {
+		t.Fatal(err)
+	}
+	testAPIKeyRepo(t, NewAPIKeyRepoPG(s.DB()))
+}
+
+// testAPIKeyRepo expects an empty repository.
+func testAPIKeyRepo(t *testing.T, r domain.APIKeyRepository) {
+	ctx := t.Context()
+	h1, h2 := strings.Repeat("1", 64), strings.Repeat("2", 64)
+	k := &domain.APIKey{Name: "sync", Prefix: "sk_abcde", Hash: h1, Scopes: []string{domain.ScopeRead}}
+	if err := r.Create(ctx, k); err != nil {
+		t.Fatal(err)
+	}
+	if k.ID == uuid.Nil || k.CreatedAt.IsZero() {
+		t.Fatalf("Create did not fill meta: %+v", k)
+	}
+	if err := r.Create(ctx, &domain.APIKey{Name: "dup", Hash: h1, Scopes: []string{domain.ScopeRead}}); !errors.Is(err, domain.ErrConflict) {
+		t.Fatalf("duplicate hash: got %v", err)
+	}
+
+	got, err := r.GetByHash(ctx, h1)
+	if err != nil || got.ID != k.ID {
+		t.Fatalf("GetByHash: got %+v, %v", got, err)
+	}
+	got.Scopes[0] = domain.ScopeAdmin
+	if again, _ := r.GetByHash(ctx, h1); again.HasScope(domain.ScopeAdmin) {
+		t.Fatal("GetByHash leaked the stored scopes")
+	}
+	if _, err := r.GetByHash(ctx, h2); !errors.Is(err, domain.ErrAPIKeyNotFound) {
+		t.Fatalf("unknown hash: got %v", err)
+	}
+
+	at := time.Now().UTC().Truncate(time.Microsecond)
+	if err := r.Touch(ctx, k.ID, at); err != nil {
+		t.Fatal(err)
+	}
+	_ = r.Touch(ctx, k.ID, at.Add(-time.Hour))
+	if got, _ := r.GetByHash(ctx, h1); got.LastUsedAt == nil || !got.LastUsedAt.Equal(at) {
+		t.Fatalf("Touch: got %v", got.LastUsedAt)
+	}
+
+	if err := r.Revoke(ctx, k.ID); err != nil {
+		t.Fatal(err)
+	}
+	first, _ := r.GetByHash(ctx, h1)
+	if err := r.Revoke(ctx, k.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := r.GetByHash(ctx, h1); got.RevokedAt == nil || !got.RevokedAt.Equal(*first.RevokedAt) || got.Active(time.Now()) {
+		t.Fatalf("Revoke: got %+v", got)
+	}
+	if err := r.Revoke(ctx, uuid.New()); !errors.Is(err, domain.ErrAPIKeyNotFound) {
+		t.Fatalf("revoke unknown: got %v", err)
+	}
+	if keys, err := r.List(ctx); err != nil || len(keys) != 1 {
+		t.Fatalf("List: got %+v, %v", keys, err)
+	}
+}
@@ -0,0 +1,201 @@
+package infastructure
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"time"
+
+	"github.com/animans/REST-API-test-task/domain"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// repoMetrics ...
+type repoMetrics struct {
+	duration *prometheus.HistogramVec
+	errors   *prometheus.CounterVec
+}
+
+// ServiceRepoInstrumented records latency and errors of every ServiceRepository call.
+type ServiceRepoInstrumented struct {
+	next    domain.ServiceRepository
+	metrics repoMetrics
+}
+
+// NewServiceRepoInstrumented wraps next and registers its collectors on reg.
+func NewServiceRepoInstrumented(next domain.ServiceRepository, reg prometheus.Registerer) *ServiceRepoInstrumented {
+	m := repoMetrics{
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "repository_operation_duration_seconds",
+			Help:    "ServiceRepository call latency by method.",
+			Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+		}, []string{"method"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "repository_operation_errors_total",
+			Help: "ServiceRepository call errors by method and kind.",
+		}, []string{"method", "kind"}),
+	}
+	reg.MustRegister(m.duration, m.errors)
+	return &ServiceRepoInstrumented{next: next, metrics: m}
+}
+
+// observe ...
+func (r *ServiceRepoInstrumented) observe(method string, start time.Time, err error) {
+	r.metrics.duration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+	if err != nil {
+		r.metrics.errors.WithLabelValues(method, errorKind(err)).Inc()
+	}
+}
+
+// errorKind keeps the kind label low-cardinality.
+func errorKind(err error) string {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		return "not_found"
+	case errors.Is(err, domain.ErrInvalidID), errors.Is(err, domain.ErrValidation):
+		return "validation"
+	case errors.Is(err, domain.ErrConflict):
+		return "conflict"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	default:
+		return "internal"
+	}
+}
+
+// Save ...
+func (r *ServiceRepoInstrumented) Save(ctx context.Context, s *domain.Service) (int, error) {
+	start := time.Now()
+	id, err := r.next.Save(ctx, s)
+	r.observe("Save", start, err)
+	return id, err
+}
+
+// GetByID ...
+func (r *ServiceRepoInstrumented) GetByID(ctx context.Context, sid string) (*domain.Service, error) {
+	start := time.Now()
+	s, err := r.next.GetByID(ctx, sid)
+	r.observe("GetByID", start, err)
+	return s, err
+}
+
+// UpdateByID ...
+func (r *ServiceRepoInstrumented) UpdateByID(ctx context.Context, sid string, s *domain.Service) error {
+	start := time.Now()
+	err := r.next.UpdateByID(ctx, sid, s)
+	r.observe("UpdateByID", start, err)
+	return err
+}
+
+// DeleteByID ...
+func (r *ServiceRepoInstrumented) DeleteByID(ctx context.Context, sid string) error {
+	start := time.Now()
+	err := r.next.DeleteByID(ctx, sid)
+	r.observe("DeleteByID", start, err)
+	return err
+}
+
+// ListByFilter ...
+func (r *ServiceRepoInstrumented) ListByFilter(ctx context.Context, f domain.ListFilterService) (domain.ListResult, error) {
+	start := time.Now()
+	out, err := r.next.ListByFilter(ctx, f)
+	r.observe("ListByFilter", start, err)
+	return out, err
+}
+
+// SumByFilter ...
+func (r *ServiceRepoInstrumented) SumByFilter(ctx context.Context, f domain.SumFilterService) (domain.SumResult, error) {
+	start := time.Now()
+	out, err := r.next.SumByFilter(ctx, f)
+	r.observe("SumByFilter", start, err)
+	return out, err
+}
+
+// SumByMonth ...
+func (r *ServiceRepoInstrumented) SumByMonth(ctx context.Context, f domain.SumFilterService) ([]domain.MonthSum, error) {
+	start := time.Now()
+	out, err := r.next.SumByMonth(ctx, f)
+	r.observe("SumByMonth", start, err)
+	return out, err
+}
+
+// SumByGroup ...
+func (r *ServiceRepoInstrumented) SumByGroup(ctx context.Context, f domain.SumFilterService) ([]domain.GroupSum, error) {
+	start := time.Now()
+	out, err := r.next.SumByGroup(ctx, f)
+	r.observe("SumByGroup", start, err)
+	return out, err
+}
+
+// Ping forwards to the wrapped repository when it is a domain.Pinger.
+func (r *ServiceRepoInstrumented) Ping(ctx context.Context) error {
+	if p, ok := r.next.(domain.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+// SchemaVersion forwards to the wrapped repository when it is a domain.SchemaVersioner.
+func (r *ServiceRepoInstrumented) SchemaVersion(ctx context.Context) (int64, bool, error) {
+	if v, ok := r.next.(domain.SchemaVersioner); ok {
+		return v.SchemaVersion(ctx)
+	}
+	return 0, false, nil
+}
+
+// SaveBatch forwards to the wrapped repository when it is a domain.BatchSaver
+// and saves one service at a time in a transaction otherwise.
+func (r *ServiceRepoInstrumented) SaveBatch(ctx context.Context, ss []*domain.Service) ([]int, error) {
+	start := time.Now()
+	var (
+		ids []int
+		err error
+	)
+	if b, ok := r.next.(domain.BatchSaver); ok {
+		ids, err = b.SaveBatch(ctx, ss)
+	} else {
+		ids = make([]int, len(ss))
+		err = r.next.WithTx(ctx, func(tx domain.ServiceRepository) error {
+			for i, s := range ss {
+				id, err := tx.Save(ctx, s)
+				if err != nil {
+					return &domain.BatchError{Index: i, Err: err}
+				}
+				ids[i] = id
+			}
+			return nil
+		})
+		if err != nil {
+			ids = nil
+		}
+	}
+	r.observe("SaveBatch", start, err)
+	return ids, err
+}
+
+// WithTx instruments the calls made through tx under their own method labels.
+func (r *ServiceRepoInstrumented) WithTx(ctx context.Context, fn func(tx domain.ServiceRepository) error) error {
+	start := time.Now()
+	err := r.next.WithTx(ctx, func(tx domain.ServiceRepository) error {
+		return fn(&ServiceRepoInstrumented{next: tx, metrics: r.metrics})
+	})
+	r.observe("WithTx", start, err)
+	return err
+}
+
+// IterateByFilter observes the whole iteration, until the consumer stops or the first error.
+func (r *ServiceRepoInstrumented) IterateByFilter(ctx context.Context, f domain.ListFilterService) iter.Seq2[*domain.Service, error] {
+	return func(yield func(*domain.Service, error) bool) {
+		start := time.Now()
+		var err error
+		defer func() { r.observe("IterateByFilter", start, err) }()
+		for s, e := range r.next.IterateByFilter(ctx, f) {
+			err = e
+			if !yield(s, e) || e != nil {
+				return
+			}
+		}
+	}
+}
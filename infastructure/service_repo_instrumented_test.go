@@ -0,0 +1,43 @@
+package infastructure
+
+import (
+	"testing"
+	"time"
+
+	"github.com/animans/REST-API-test-task/domain"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestInstrumentedRepo(t *testing.T) {
+	r := NewServiceRepoInstrumented(NewServiceRepoMem(), prometheus.NewRegistry())
+	ctx := t.Context()
+
+	if _, err := r.Save(ctx, domain.NewService("Netflix", 800, uuid.New(), time.Now())); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if _, err := r.GetByID(ctx, "1"); err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if _, err := r.GetByID(ctx, "42"); err == nil {
+		t.Fatal("expected not found")
+	}
+	if _, err := r.GetByID(ctx, "x"); err == nil {
+		t.Fatal("expected invalid id")
+	}
+
+	if got := testutil.CollectAndCount(r.metrics.duration, "repository_operation_duration_seconds"); got != 2 {
+		t.Fatalf("duration series: got %d, want 2", got)
+	}
+	errs := r.metrics.errors
+	if got := testutil.ToFloat64(errs.WithLabelValues("GetByID", "not_found")); got != 1 {
+		t.Fatalf("not_found errors: got %v", got)
+	}
+	if got := testutil.ToFloat64(errs.WithLabelValues("GetByID", "validation")); got != 1 {
+		t.Fatalf("validation errors: got %v", got)
+	}
+	if got := testutil.ToFloat64(errs.WithLabelValues("Save", "internal")); got != 0 {
+		t.Fatalf("Save errors: got %v", got)
+	}
+}
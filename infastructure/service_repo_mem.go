@@ -0,0 +1,447 @@
+package infastructure
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/animans/REST-API-test-task/domain"
+	"github.com/animans/REST-API-test-task/logging"
+)
+
+// ServiceRepoMem is an in-memory ServiceRepository with the same semantics as ServiceRepoPG.
+type ServiceRepoMem struct {
+	mu     sync.RWMutex
+	nextID int
+	rows   map[int]*domain.Service
+	now    func() time.Time
+}
+
+// NewServiceRepoMem ...
+func NewServiceRepoMem() *ServiceRepoMem {
+	return &ServiceRepoMem{
+		rows: make(map[int]*domain.Service),
+		now:  time.Now,
+	}
+}
+
+// Open ...
+func (r *ServiceRepoMem) Open() error {
+	slog.Debug("Open done", "storage", "memory")
+	return nil
+}
+
+// Close ...
+func (r *ServiceRepoMem) Close() error {
+	slog.Debug("Close done", "storage", "memory")
+	return nil
+}
+
+// Ping ...
+func (r *ServiceRepoMem) Ping(ctx context.Context) error {
+	return ctx.Err()
+}
+
+// checkService mirrors the service_list table constraints.
+func checkService(s *domain.Service) error {
+	if ed := s.GetEndDate(); ed != nil && ed.Before(s.GetStartDate()) {
+		return fmt.Errorf("%w: end date before start date", domain.ErrValidation)
+	}
+	return nil
+}
+
+// Save ...
+func (r *ServiceRepoMem) Save(ctx context.Context, s *domain.Service) (int, error) {
+	log := logging.FromContext(ctx)
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	if err := checkService(s); err != nil {
+		log.Error("Save check error", "err", err)
+		return 0, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	now := r.now().UTC()
+	s.SetMeta(r.nextID, now, now)
+	r.rows[r.nextID] = s.Clone()
+
+	log.Debug("Save done", "id", r.nextID)
+	return r.nextID, nil
+}
+
+// SaveBatch checks every service before saving any of them.
+func (r *ServiceRepoMem) SaveBatch(ctx context.Context, ss []*domain.Service) ([]int, error) {
+	log := logging.FromContext(ctx)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	for i, s := range ss {
+		if err := checkService(s); err != nil {
+			log.Error("SaveBatch check error", "index", i, "err", err)
+			return nil, &domain.BatchError{Index: i, Err: err}
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ids := make([]int, len(ss))
+	now := r.now().UTC()
+	for i, s := range ss {
+		r.nextID++
+		s.SetMeta(r.nextID, now, now)
+		r.rows[r.nextID] = s.Clone()
+		ids[i] = r.nextID
+	}
+
+	log.Debug("SaveBatch done", "count", len(ids))
+	return ids, nil
+}
+
+// WithTx runs fn on a copy of the rows and keeps it when fn succeeds. Other
+// calls wait until fn returns, so transactions are serializable.
+func (r *ServiceRepoMem) WithTx(ctx context.Context, fn func(tx domain.ServiceRepository) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tx := &ServiceRepoMem{nextID: r.nextID, rows: make(map[int]*domain.Service, len(r.rows)), now: r.now}
+	for id, s := range r.rows {
+		tx.rows[id] = s
+	}
+	if err := fn(tx); err != nil {
+		logging.FromContext(ctx).Debug("WithTx rollback", "err", err)
+		return err
+	}
+	// Rows are never modified in place, so sharing them with the copy is safe.
+	r.rows, r.nextID = tx.rows, tx.nextID
+	return nil
+}
+
+// GetByID ...
+func (r *ServiceRepoMem) GetByID(ctx context.Context, sid string) (*domain.Service, error) {
+	log := logging.FromContext(ctx)
+	if err := ctx.Err(); err != nil {
+		return &domain.Service{}, err
+	}
+	id, err := domain.ParseID(sid)
+	if err != nil {
+		log.Error("GetByID ParseID error", "err", err)
+		return &domain.Service{}, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	s, ok := r.rows[id]
+	if !ok {
+		log.Debug("GetByID not found", "id", id)
+		return &domain.Service{}, fmt.Errorf("%w: id=%d", domain.ErrNotFound, id)
+	}
+
+	log.Debug("GetByID done", "id", id)
+	return s.Clone(), nil
+}
+
+// UpdateByID ...
+func (r *ServiceRepoMem) UpdateByID(ctx context.Context, sid string, in *domain.Service) error {
+	log := logging.FromContext(ctx)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	id, err := domain.ParseID(sid)
+	if err != nil {
+		log.Error("UpdateByID id error", "err", err)
+		return err
+	}
+	if err := checkService(in); err != nil {
+		log.Error("UpdateByID check error", "err", err)
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	old, ok := r.rows[id]
+	if !ok {
+		log.Error("UpdateByID not found", "id", id)
+		return fmt.Errorf("%w: id=%d", domain.ErrNotFound, id)
+	}
+	s := in.Clone()
+	s.SetMeta(id, old.GetCreatedAt(), r.now().UTC())
+	r.rows[id] = s
+
+	log.Debug("UpdateByID done")
+	return nil
+}
+
+// DeleteByID ...
+func (r *ServiceRepoMem) DeleteByID(ctx context.Context, sid string) error {
+	log := logging.FromContext(ctx)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	id, err := domain.ParseID(sid)
+	if err != nil {
+		log.Error("DeleteByID id error", "err", err)
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.rows[id]; !ok {
+		log.Error("DeleteByID not found", "id", id)
+		return fmt.Errorf("%w: id=%d", domain.ErrNotFound, id)
+	}
+	delete(r.rows, id)
+
+	log.Debug("DeleteByID done")
+	return nil
+}
+
+// memFilter holds the predicates shared by list and summary queries.
+type memFilter struct {
+	name  string
+	price int
+	uuid  string
+	from  *time.Time
+	to    *time.Time
+}
+
+// match ...
+func (f memFilter) match(s *domain.Service) bool {
+	if f.name != "" && !strings.Contains(strings.ToLower(s.GetName()), strings.ToLower(f.name)) {
+		return false
+	}
+	if f.price > 0 && s.GetPrice() != f.price {
+		return false
+	}
+	if f.uuid != "" && s.GetUUID().String() != f.uuid {
+		return false
+	}
+	if f.from != nil && s.GetEndDate() != nil && s.GetEndDate().Before(*f.from) {
+		return false
+	}
+	if f.to != nil && s.GetStartDate().After(*f.to) {
+		return false
+	}
+	return true
+}
+
+// selectRows returns clones of the rows matching f, in id order.
+func (r *ServiceRepoMem) selectRows(f memFilter) []*domain.Service {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]*domain.Service, 0, len(r.rows))
+	for _, s := range r.rows {
+		if f.match(s) {
+			out = append(out, s.Clone())
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].GetID() < out[j].GetID() })
+	return out
+}
+
+// compareCursor compares a with the position c by sort column, then by id.
+func compareCursor(a *domain.Service, c domain.Cursor) int {
+	var n int
+	switch c.SortBy {
+	case "service_price":
+		v, _ := strconv.Atoi(c.Value)
+		n = a.GetPrice() - v
+	case "service_name":
+		n = strings.Compare(a.GetName(), c.Value)
+	default:
+		n = strings.Compare(a.GetStartDate().Format("2006-01-02"), c.Value)
+	}
+	if n == 0 {
+		n = a.GetID() - c.ID
+	}
+	return n
+}
+
+// listRows returns clones of the rows matching s in its sort order, after
+// defaulting the sort of s; asc is the direction the rows are in.
+func (r *ServiceRepoMem) listRows(s *domain.ListFilterService) (rows []*domain.Service, asc bool) {
+	f := memFilter{name: s.Name, price: s.Price, from: s.From, to: s.To}
+	if s.Uuid != nil {
+		f.uuid = s.Uuid.String()
+	}
+	rows = r.selectRows(f)
+
+	if _, ok := sortColumns[s.SortBy]; !ok {
+		s.SortBy = "service_created_at"
+	}
+	if s.SortDir != "asc" {
+		s.SortDir = "desc"
+	}
+	asc = s.SortDir == "asc"
+	if s.Cursor != nil && s.Cursor.Backward {
+		asc = !asc
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		c := compareCursor(rows[i], domain.CursorAt(rows[j], s.SortBy, s.SortDir, false))
+		if asc {
+			return c < 0
+		}
+		return c > 0
+	})
+	return rows, asc
+}
+
+// ListByFilter ...
+func (r *ServiceRepoMem) ListByFilter(ctx context.Context, s domain.ListFilterService) (domain.ListResult, error) {
+	log := logging.FromContext(ctx)
+	if err := ctx.Err(); err != nil {
+		return domain.ListResult{}, err
+	}
+	rows, asc := r.listRows(&s)
+	total := len(rows)
+
+	fetched := make([]*domain.Service, 0, s.Limit+1)
+	for _, row := range rows {
+		if c := s.Cursor; c != nil {
+			cmp := compareCursor(row, *c)
+			if (asc && cmp <= 0) || (!asc && cmp >= 0) {
+				continue
+			}
+		}
+		fetched = append(fetched, row)
+		if len(fetched) > s.Limit {
+			break
+		}
+	}
+
+	out := domain.NewListPage(fetched, s)
+	if s.WithTotal {
+		out.Total = &total
+	}
+	log.Debug("ListByFilter done", "items", len(out.Items), "total", total)
+	return out, nil
+}
+
+// IterateByFilter yields a snapshot of the matching rows taken when iteration starts.
+func (r *ServiceRepoMem) IterateByFilter(ctx context.Context, s domain.ListFilterService) iter.Seq2[*domain.Service, error] {
+	return func(yield func(*domain.Service, error) bool) {
+		if err := ctx.Err(); err != nil {
+			yield(nil, err)
+			return
+		}
+		s.Cursor = nil
+		rows, _ := r.listRows(&s)
+		for _, row := range rows {
+			if !yield(row, nil) {
+				return
+			}
+		}
+		logging.FromContext(ctx).Debug("IterateByFilter done", "rows", len(rows))
+	}
+}
+
+// sumRows ...
+func (r *ServiceRepoMem) sumRows(s domain.SumFilterService) []*domain.Service {
+	f := memFilter{name: s.Name, from: s.From, to: s.To}
+	if s.Uuid != nil {
+		f.uuid = s.Uuid.String()
+	}
+	return r.selectRows(f)
+}
+
+// SumByFilter ...
+func (r *ServiceRepoMem) SumByFilter(ctx context.Context, s domain.SumFilterService) (domain.SumResult, error) {
+	log := logging.FromContext(ctx)
+	if err := ctx.Err(); err != nil {
+		return domain.SumResult{}, err
+	}
+	end := s.PeriodEnd(r.now())
+	var total domain.SumResult
+	for _, row := range r.sumRows(s) {
+		total.Total += row.GetPrice() * row.ActiveMonths(s.From, end)
+	}
+
+	log.Debug("SumByFilter done", "total", total)
+	return total, nil
+}
+
+// SumByMonth ...
+func (r *ServiceRepoMem) SumByMonth(ctx context.Context, s domain.SumFilterService) ([]domain.MonthSum, error) {
+	log := logging.FromContext(ctx)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	out := []domain.MonthSum{}
+	if s.From == nil {
+		return out, nil
+	}
+	f := memFilter{name: s.Name}
+	if s.Uuid != nil {
+		f.uuid = s.Uuid.String()
+	}
+	rows := r.selectRows(f)
+	end := s.PeriodEnd(r.now())
+	for m := *s.From; !m.After(end); m = m.AddDate(0, 1, 0) {
+		ms := domain.MonthSum{Month: m.Format(domain.MonthLayout)}
+		for _, row := range rows {
+			if row.GetStartDate().After(m) || (row.GetEndDate() != nil && row.GetEndDate().Before(m)) {
+				continue
+			}
+			ms.Total += row.GetPrice()
+			ms.Count++
+		}
+		out = append(out, ms)
+	}
+
+	log.Debug("SumByMonth done", "months", len(out))
+	return out, nil
+}
+
+// SumByGroup ...
+func (r *ServiceRepoMem) SumByGroup(ctx context.Context, s domain.SumFilterService) ([]domain.GroupSum, error) {
+	log := logging.FromContext(ctx)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if _, ok := groupColumns[s.GroupBy]; !ok {
+		return nil, fmt.Errorf("%w: group_by %q", domain.ErrValidation, s.GroupBy)
+	}
+	end := s.PeriodEnd(r.now())
+	groups := map[string]*domain.GroupSum{}
+	for _, row := range r.sumRows(s) {
+		n := row.ActiveMonths(s.From, end)
+		if n == 0 {
+			continue
+		}
+		key := row.GetName()
+		if s.GroupBy == domain.GroupByUser {
+			key = row.GetUUID().String()
+		}
+		g, ok := groups[key]
+		if !ok {
+			g = &domain.GroupSum{Key: key}
+			groups[key] = g
+		}
+		g.Total += row.GetPrice() * n
+		g.Count++
+	}
+
+	out := make([]domain.GroupSum, 0, len(groups))
+	for _, g := range groups {
+		out = append(out, *g)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Total != out[j].Total {
+			return out[i].Total > out[j].Total
+		}
+		return out[i].Key < out[j].Key
+	})
+
+	log.Debug("SumByGroup done", "groups", len(out))
+	return out, nil
+}
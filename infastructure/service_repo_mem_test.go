@@ -0,0 +1,83 @@
+package infastructure
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/animans/REST-API-test-task/domain"
+	"github.com/animans/REST-API-test-task/domain/repotest"
+	"github.com/google/uuid"
+)
+
+func TestMemConformance(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) domain.ServiceRepository {
+		return NewServiceRepoMem()
+	})
+}
+
+func TestMemCRUD(t *testing.T) {
+	r := NewServiceRepoMem()
+	sdate := time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC)
+	s := domain.NewService("Yandex Plus", 400, uuid.New(), sdate)
+
+	id, err := r.Save(t.Context(), s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.GetID() != id || s.GetCreatedAt().IsZero() {
+		t.Fatalf("Save did not fill meta: id=%d created=%s", s.GetID(), s.GetCreatedAt())
+	}
+
+	got, err := r.GetByID(t.Context(), strconv.Itoa(id))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.GetName() != "Yandex Plus" || got.GetPrice() != 400 || !got.GetStartDate().Equal(sdate) {
+		t.Fatalf("GetByID: %#v", got)
+	}
+
+	if err := r.UpdateByID(t.Context(), strconv.Itoa(id), domain.NewService("GPT Plus", 500, uuid.New(), sdate)); err != nil {
+		t.Fatal(err)
+	}
+	got, _ = r.GetByID(t.Context(), strconv.Itoa(id))
+	if got.GetName() != "GPT Plus" || got.GetID() != id || !got.GetCreatedAt().Equal(s.GetCreatedAt()) {
+		t.Fatalf("UpdateByID: %#v", got)
+	}
+
+	if err := r.DeleteByID(t.Context(), strconv.Itoa(id)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.GetByID(t.Context(), strconv.Itoa(id)); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("GetByID after delete: got err=%v want ErrNotFound", err)
+	}
+	if err := r.DeleteByID(t.Context(), "x"); !errors.Is(err, domain.ErrInvalidID) {
+		t.Fatalf("DeleteByID(x): got err=%v want ErrInvalidID", err)
+	}
+}
+
+func TestMemConcurrentSave(t *testing.T) {
+	r := NewServiceRepoMem()
+	var wg sync.WaitGroup
+	ids := make([]int, 50)
+	for i := range ids {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			s := domain.NewService("S", i, uuid.New(), time.Now())
+			ids[i], _ = r.Save(t.Context(), s)
+			_, _ = r.ListByFilter(t.Context(), domain.ListFilterService{Limit: 10})
+		}(i)
+	}
+	wg.Wait()
+
+	seen := map[int]bool{}
+	for _, id := range ids {
+		if id == 0 || seen[id] {
+			t.Fatalf("duplicate or missing id %d in %v", id, ids)
+		}
+		seen[id] = true
+	}
+}
@@ -1,37 +1,128 @@
 package infastructure
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"iter"
 	"log/slog"
-	"os"
-	"strconv"
+	"regexp"
 	"strings"
 	"time"
 
 	"github.com/animans/REST-API-test-task/domain"
+	"github.com/animans/REST-API-test-task/logging"
 	"github.com/google/uuid"
-	_ "github.com/lib/pq" // ...
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// DefaultQueryTimeout ...
+const DefaultQueryTimeout = 5 * time.Second
+
 // ServiceRepoPG ...
 type ServiceRepoPG struct {
-	db *sql.DB
+	db  *sql.DB
+	tx  *sql.Tx
+	dsn string
+	// QueryTimeout bounds every repository call, zero disables it.
+	QueryTimeout time.Duration
+	// Tracer records a span per repository method, the global provider by default.
+	Tracer trace.Tracer
 }
 
 // NewServiceRepoPG ...
-func NewServiceRepoPG() *ServiceRepoPG {
-	return &ServiceRepoPG{}
+func NewServiceRepoPG(dsn string) *ServiceRepoPG {
+	return &ServiceRepoPG{
+		dsn:          dsn,
+		QueryTimeout: DefaultQueryTimeout,
+		Tracer:       otel.Tracer("github.com/animans/REST-API-test-task/infastructure"),
+	}
+}
+
+// withTimeout ...
+func (r *ServiceRepoPG) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if r.QueryTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, r.QueryTimeout)
+}
+
+// startSpan starts a client span for a repository method.
+func (r *ServiceRepoPG) startSpan(ctx context.Context, method string) (context.Context, trace.Span) {
+	return r.Tracer.Start(ctx, "ServiceRepoPG."+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemNamePostgreSQL, semconv.DBOperationName(method)),
+	)
+}
+
+// querier is implemented by *sql.DB and *sql.Tx.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// conn runs queries in the transaction of a WithTx repository, on the pool otherwise.
+func (r *ServiceRepoPG) conn() querier {
+	if r.tx != nil {
+		return r.tx
+	}
+	return r.db
+}
+
+// WithTx runs fn with a repository bound to one transaction, committed when fn
+// returns nil and rolled back otherwise. Nested calls join the outer transaction.
+func (r *ServiceRepoPG) WithTx(ctx context.Context, fn func(tx domain.ServiceRepository) error) error {
+	return r.withTx(ctx, func(tx *ServiceRepoPG) error { return fn(tx) })
+}
+
+// withTx ...
+func (r *ServiceRepoPG) withTx(ctx context.Context, fn func(tx *ServiceRepoPG) error) error {
+	if r.tx != nil {
+		return fn(r)
+	}
+	log := logging.FromContext(ctx)
+	ctx, span := r.startSpan(ctx, "WithTx")
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error("WithTx BeginTx error", "err", err)
+		return mapPQError(ctx, err)
+	}
+	defer tx.Rollback()
+	if err := fn(&ServiceRepoPG{db: r.db, tx: tx, dsn: r.dsn, QueryTimeout: r.QueryTimeout, Tracer: r.Tracer}); err != nil {
+		log.Debug("WithTx rollback", "err", err)
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		log.Error("WithTx Commit error", "err", err)
+		return mapPQError(ctx, err)
+	}
+	return nil
+}
+
+// sqlLiteral matches quoted SQL string literals.
+var sqlLiteral = regexp.MustCompile(`'(?:[^']|'')*'`)
+
+// statement records q on the span in ctx and returns it unchanged.
+// Bind arguments are never recorded and inline literals are masked.
+func statement(ctx context.Context, q string) string {
+	trace.SpanFromContext(ctx).SetAttributes(semconv.DBQueryText(sqlLiteral.ReplaceAllString(strings.TrimSpace(q), "'?'")))
+	return q
 }
 
 // Open ...
 func (r *ServiceRepoPG) Open() error {
-	env, ok := os.LookupEnv("DATABASE_URL")
-	if !ok {
-		slog.Warn("env DATABASE_URL not found")
-		env = "host=localhost user=baish password=postgres port=5432 dbname=REST-API-task-test_test sslmode=disable"
+	if r.dsn == "" {
+		return errors.New("database url is not set")
 	}
-	db, err := sql.Open("postgres", env)
+	db, err := sql.Open("postgres", r.dsn)
 	if err != nil {
 		slog.Error("Open open error", "err", err)
 		return err
@@ -48,236 +139,646 @@ func (r *ServiceRepoPG) Open() error {
 	return nil
 }
 
+// DB exposes the connection pool, e.g. for sql.DBStats collectors.
+func (r *ServiceRepoPG) DB() *sql.DB {
+	return r.db
+}
+
 // Close ...
 func (r *ServiceRepoPG) Close() error {
 	slog.Debug("Close done")
 	return r.db.Close()
 }
 
+// Ping ...
+func (r *ServiceRepoPG) Ping(ctx context.Context) error {
+	log := logging.FromContext(ctx)
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+	ctx, span := r.startSpan(ctx, "Ping")
+	defer span.End()
+	if err := r.db.PingContext(ctx); err != nil {
+		log.Error("Ping error", "err", err)
+		return mapPQError(ctx, err)
+	}
+	return nil
+}
+
+// SchemaVersion reads the golang-migrate schema_migrations table.
+func (r *ServiceRepoPG) SchemaVersion(ctx context.Context) (int64, bool, error) {
+	log := logging.FromContext(ctx)
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+	ctx, span := r.startSpan(ctx, "SchemaVersion")
+	defer span.End()
+	var (
+		version int64
+		dirty   bool
+	)
+	err := r.conn().QueryRowContext(ctx, statement(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1")).Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		log.Error("SchemaVersion Query error", "err", err)
+		return 0, false, mapPQError(ctx, err)
+	}
+	return version, dirty, nil
+}
+
 // Save ...
-func (r *ServiceRepoPG) Save(s *domain.Service) (int, error) {
-	var id int
+func (r *ServiceRepoPG) Save(ctx context.Context, s *domain.Service) (int, error) {
+	log := logging.FromContext(ctx)
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+	ctx, span := r.startSpan(ctx, "Save")
+	defer span.End()
 
-	if err := r.db.QueryRow(
-		"INSERT INTO service_list (service_price, service_name, service_uuid, service_created_at) VALUES ($1, $2, $3, $4) RETURNING service_id",
-		s.GetPrice(), s.GetName(), s.GetUUID(), s.GetStartDate(),
-	).Scan(&id); err != nil {
-		slog.Error("Save Query error", "err", err)
-		return 0, err
+	var (
+		id               int
+		created, updated time.Time
+	)
+
+	if err := r.conn().QueryRowContext(ctx,
+		statement(ctx, "INSERT INTO service_list (service_price, service_name, service_uuid, service_created_at, service_ended_at) VALUES ($1, $2, $3, $4, $5) RETURNING service_id, service_inserted_at, service_updated_at"),
+		s.GetPrice(), s.GetName(), s.GetUUID(), s.GetStartDate(), s.GetEndDate(),
+	).Scan(&id, &created, &updated); err != nil {
+		log.Error("Save Query error", "err", err)
+		return 0, mapPQError(ctx, err)
 	}
+	s.SetMeta(id, created, updated)
 
-	slog.Debug("Save done", "id", id)
+	log.Debug("Save done", "id", id)
 	return id, nil
 }
 
+// SaveBatch inserts ss in one transaction with a prepared statement.
+// QueryTimeout bounds each insert rather than the whole batch.
+func (r *ServiceRepoPG) SaveBatch(ctx context.Context, ss []*domain.Service) ([]int, error) {
+	log := logging.FromContext(ctx)
+	ctx, span := r.startSpan(ctx, "SaveBatch")
+	defer span.End()
+
+	type meta struct {
+		id               int
+		created, updated time.Time
+	}
+	rows := make([]meta, len(ss))
+	err := r.withTx(ctx, func(tx *ServiceRepoPG) error {
+		stmt, err := tx.tx.PrepareContext(ctx,
+			statement(ctx, "INSERT INTO service_list (service_price, service_name, service_uuid, service_created_at, service_ended_at) VALUES ($1, $2, $3, $4, $5) RETURNING service_id, service_inserted_at, service_updated_at"),
+		)
+		if err != nil {
+			log.Error("SaveBatch Prepare error", "err", err)
+			return mapPQError(ctx, err)
+		}
+		defer stmt.Close()
+		for i, s := range ss {
+			if err := r.insert(ctx, stmt, s, &rows[i].id, &rows[i].created, &rows[i].updated); err != nil {
+				log.Error("SaveBatch Query error", "index", i, "err", err)
+				return &domain.BatchError{Index: i, Err: err}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, len(ss))
+	for i, s := range ss {
+		s.SetMeta(rows[i].id, rows[i].created, rows[i].updated)
+		ids[i] = rows[i].id
+	}
+	log.Debug("SaveBatch done", "count", len(ids))
+	return ids, nil
+}
+
+// insert runs the prepared insert of SaveBatch for s under QueryTimeout.
+func (r *ServiceRepoPG) insert(ctx context.Context, stmt *sql.Stmt, s *domain.Service, dest ...any) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+	if err := stmt.QueryRowContext(ctx, s.GetPrice(), s.GetName(), s.GetUUID(), s.GetStartDate(), s.GetEndDate()).Scan(dest...); err != nil {
+		return mapPQError(ctx, err)
+	}
+	return nil
+}
+
+// mapPQError translates constraint violations and cancellations into domain and context errors.
+func mapPQError(ctx context.Context, err error) error {
+	span := trace.SpanFromContext(ctx)
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+	if ctxErr := ctx.Err(); ctxErr != nil && !errors.Is(err, ctxErr) {
+		return fmt.Errorf("%w: %v", ctxErr, err)
+	}
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+	switch pqErr.Code.Name() {
+	case "unique_violation", "exclusion_violation":
+		return fmt.Errorf("%w: %s", domain.ErrConflict, pqErr.Message)
+	case "check_violation", "not_null_violation", "string_data_right_truncation":
+		return fmt.Errorf("%w: %s", domain.ErrValidation, pqErr.Message)
+	}
+	return err
+}
+
+// serviceColumns lists the columns scanned by repoService.scan.
+const serviceColumns = "service_id, service_name, service_price, service_uuid, service_created_at, service_ended_at, service_inserted_at, service_updated_at"
+
 // repoService ...
 type repoService struct {
-	Name  string
-	Price int
-	Uuid  uuid.UUID
-	Date  time.Time
+	ID      int
+	Name    string
+	Price   int
+	Uuid    uuid.UUID
+	Date    time.Time
+	End     *time.Time
+	Created time.Time
+	Updated time.Time
+}
+
+// rowScanner is implemented by *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scan ...
+func (in *repoService) scan(row rowScanner) error {
+	return row.Scan(&in.ID, &in.Name, &in.Price, &in.Uuid, &in.Date, &in.End, &in.Created, &in.Updated)
+}
+
+// toService ...
+func (in *repoService) toService() *domain.Service {
+	ser := domain.NewService(in.Name, in.Price, in.Uuid, in.Date)
+	ser.SetEndDate(in.End)
+	ser.SetMeta(in.ID, in.Created, in.Updated)
+	return ser
 }
 
 // GetByID ...
-func (r *ServiceRepoPG) GetByID(sid string) (*domain.Service, error) {
+func (r *ServiceRepoPG) GetByID(ctx context.Context, sid string) (*domain.Service, error) {
+	log := logging.FromContext(ctx)
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+	ctx, span := r.startSpan(ctx, "GetByID")
+	defer span.End()
+
 	var in repoService
 
-	id, err := strconv.Atoi(sid)
+	id, err := domain.ParseID(sid)
 	if err != nil {
-		slog.Error("GetByID Atoi error", "err", err)
+		log.Error("GetByID ParseID error", "err", err)
 		return &domain.Service{}, err
 	}
-	if err := r.db.QueryRow(
-		"SELECT service_name, service_price, service_uuid, service_created_at FROM service_list WHERE service_id=$1",
-		id,
-	).Scan(&in.Name, &in.Price, &in.Uuid, &in.Date); err != nil {
-		slog.Error("GetByID Query error", "err", err)
-		return &domain.Service{}, err
+	query := "SELECT " + serviceColumns + " FROM service_list WHERE service_id=$1"
+	if r.tx != nil {
+		// Hold the row until the transaction ends, so checks on it stay true for the writes after.
+		query += " FOR UPDATE"
+	}
+	if err := in.scan(r.conn().QueryRowContext(ctx, statement(ctx, query), id)); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			log.Debug("GetByID not found", "id", id)
+			return &domain.Service{}, fmt.Errorf("%w: id=%d", domain.ErrNotFound, id)
+		}
+		log.Error("GetByID Query error", "err", err)
+		return &domain.Service{}, mapPQError(ctx, err)
 	}
 
-	slog.Debug("GetByID done", "in.Name", in.Name, "in.Price", in.Price, "in.Uuid", in.Uuid, "in.Date", in.Date)
-	return domain.NewService(in.Name, in.Price, in.Uuid, in.Date), nil
+	log.Debug("GetByID done", "in.Name", in.Name, "in.Price", in.Price, "in.Uuid", in.Uuid, "in.Date", in.Date, "in.End", in.End)
+	return in.toService(), nil
 }
 
 // UpdateByID ...
-func (r *ServiceRepoPG) UpdateByID(sid string, in *domain.Service) error {
-	id, err := strconv.Atoi(sid)
+func (r *ServiceRepoPG) UpdateByID(ctx context.Context, sid string, in *domain.Service) error {
+	log := logging.FromContext(ctx)
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+	ctx, span := r.startSpan(ctx, "UpdateByID")
+	defer span.End()
+
+	id, err := domain.ParseID(sid)
 	if err != nil {
-		slog.Error("UpdateByID id error", "err", err)
+		log.Error("UpdateByID id error", "err", err)
 		return err
 	}
-	res, err := r.db.Exec(
-		"UPDATE service_list SET service_name=$1, service_price=$2, service_uuid=$3, service_created_at=$4 WHERE service_id=$5",
-		in.GetName(), in.GetPrice(), in.GetUUID().String(), in.GetStartDate(),
+	res, err := r.conn().ExecContext(ctx,
+		statement(ctx, "UPDATE service_list SET service_name=$1, service_price=$2, service_uuid=$3, service_created_at=$4, service_ended_at=$5, service_updated_at=now() WHERE service_id=$6"),
+		in.GetName(), in.GetPrice(), in.GetUUID().String(), in.GetStartDate(), in.GetEndDate(),
 		id,
 	)
 	if err != nil {
-		slog.Error("UpdateByID Exec error", "err", err)
-		return err
+		log.Error("UpdateByID Exec error", "err", err)
+		return mapPQError(ctx, err)
 	}
 	rows, err := res.RowsAffected()
 	if err != nil {
-		slog.Error("UpdateByID RowsAddected error", "err", err)
+		log.Error("UpdateByID RowsAddected error", "err", err)
 		return err
 	}
 	if rows == 0 {
-		slog.Error("UpdateByID RowsAddected zero row", "rows", rows)
-		return fmt.Errorf("no rows updated, id=%d", id)
+		log.Error("UpdateByID RowsAddected zero row", "rows", rows)
+		return fmt.Errorf("%w: id=%d", domain.ErrNotFound, id)
 	}
 
-	slog.Debug("UpdateBeID done")
+	log.Debug("UpdateBeID done")
 	return nil
 }
 
 // DeleteByID ...
-func (r *ServiceRepoPG) DeleteByID(sid string) error {
-	id, err := strconv.Atoi(sid)
+func (r *ServiceRepoPG) DeleteByID(ctx context.Context, sid string) error {
+	log := logging.FromContext(ctx)
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+	ctx, span := r.startSpan(ctx, "DeleteByID")
+	defer span.End()
+
+	id, err := domain.ParseID(sid)
 	if err != nil {
-		slog.Error("DeleteByID id error", "err", err)
+		log.Error("DeleteByID id error", "err", err)
 		return err
 	}
-	res, err := r.db.Exec(
-		"DELETE FROM service_list WHERE service_id=$1",
+	res, err := r.conn().ExecContext(ctx,
+		statement(ctx, "DELETE FROM service_list WHERE service_id=$1"),
 		id,
 	)
 	if err != nil {
-		slog.Error("DeleteByID Exec error", "err", err)
-		return err
+		log.Error("DeleteByID Exec error", "err", err)
+		return mapPQError(ctx, err)
 	}
 	rows, err := res.RowsAffected()
 	if err != nil {
-		slog.Error("DeleteByID Rows error", "err", err)
+		log.Error("DeleteByID Rows error", "err", err)
 		return err
 	}
 	if rows == 0 {
-		slog.Error("DeleteByID Rows zero row", "rows", rows)
-		return fmt.Errorf("no rows deleted, id=%d", id)
+		log.Error("DeleteByID Rows zero row", "rows", rows)
+		return fmt.Errorf("%w: id=%d", domain.ErrNotFound, id)
 	}
 
-	slog.Debug("DeleteByID done")
+	log.Debug("DeleteByID done")
 	return nil
 }
 
+// sortColumns maps sortable columns to the SQL type of their cursor value.
+var sortColumns = map[string]string{
+	"service_created_at": "date",
+	"service_price":      "integer",
+	"service_name":       "text",
+}
+
 // ListByFilter ...
-func (r *ServiceRepoPG) ListByFilter(s domain.ListFilterService) (domain.ListResult, error) {
-	var (
-		args   []any
-		values []string
-	)
+func (r *ServiceRepoPG) ListByFilter(ctx context.Context, s domain.ListFilterService) (domain.ListResult, error) {
+	log := logging.FromContext(ctx)
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+	ctx, span := r.startSpan(ctx, "ListByFilter")
+	defer span.End()
+
 	base := `
-SELECT service_name, service_price, service_uuid, service_created_at
+SELECT ` + serviceColumns + `
 FROM service_list
 `
+	args, values := listFilter(s)
 
-	if s.Name != "" {
-		args = append(args, "%"+s.Name+"%")
-		values = append(values, fmt.Sprintf("service_name ILIKE $%d", len(args)))
-	}
-	if s.Price > 0 {
-		args = append(args, s.Price)
-		values = append(values, fmt.Sprintf("service_price=$%d", len(args)))
-	}
-	if s.FromStartDate != nil {
-		args = append(args, s.FromStartDate)
-		values = append(values, fmt.Sprintf("service_created_at>=$%d", len(args)))
-	}
-	if s.ToStartDate != nil {
-		args = append(args, s.ToStartDate)
-		values = append(values, fmt.Sprintf("service_created_at<=$%d", len(args)))
+	var total *int
+	if s.WithTotal {
+		n, err := r.count(ctx, values, args)
+		if err != nil {
+			return domain.ListResult{}, err
+		}
+		total = &n
 	}
 
-	var where string
-	if len(values) > 0 {
-		where = "WHERE " + strings.Join(values, " AND ") + "\n"
+	typ := listSort(&s)
+	dir := s.SortDir
+	if c := s.Cursor; c != nil {
+		op := ">"
+		if (s.SortDir == "desc") != c.Backward {
+			op = "<"
+		}
+		if c.Backward {
+			dir = map[string]string{"asc": "desc", "desc": "asc"}[dir]
+		}
+		args = append(args, c.Value, c.ID)
+		values = append(values, fmt.Sprintf("(%s, service_id) %s ($%d::%s, $%d)", sortKey(s.SortBy), op, len(args)-1, typ, len(args)))
 	}
 
-	order := fmt.Sprintf("ORDER BY %s %s\n", s.SortBy, s.SortDir)
-
-	args = append(args, s.Limit)
+	args = append(args, s.Limit+1)
 	limit := fmt.Sprintf("LIMIT $%d\n", len(args))
 
-	sql := base + where + order + limit
+	sql := base + where(values) + orderBy(s.SortBy, dir) + limit
 
-	rows, err := r.db.Query(sql, args...)
+	rows, err := r.conn().QueryContext(ctx, statement(ctx, sql), args...)
 	if err != nil {
-		slog.Error("ListByFilter Query error", "err", err)
-		return domain.ListResult{}, err
+		log.Error("ListByFilter Query error", "err", err)
+		return domain.ListResult{}, mapPQError(ctx, err)
 	}
 	defer rows.Close()
 
-	out := domain.ListResult{}
+	var fetched []*domain.Service
 	for rows.Next() {
-		var cr domain.CreatedRequest
-		var startDate time.Time
-		var uuid uuid.UUID
-		if err := rows.Scan(&cr.Name, &cr.Price, &uuid, &startDate); err != nil {
-			slog.Error("ListByFilter Scan error", "err", err)
+		var in repoService
+		if err := in.scan(rows); err != nil {
+			log.Error("ListByFilter Scan error", "err", err)
 			return domain.ListResult{}, err
 		}
-		cr.StartDate = startDate.Format("01-2006")
-		cr.Uuid = uuid.String()
-		out.Items = append(out.Items, cr)
+		fetched = append(fetched, in.toService())
 	}
 	if err := rows.Err(); err != nil {
-		slog.Error("ListByFilter Err error", "err", err)
-		return domain.ListResult{}, err
+		log.Error("ListByFilter Err error", "err", err)
+		return domain.ListResult{}, mapPQError(ctx, err)
 	}
 
-	slog.Debug("ListByFilter done", "out", out)
+	out := domain.NewListPage(fetched, s)
+	out.Total = total
+	log.Debug("ListByFilter done", "items", len(out.Items), "total", total)
 	return out, nil
 }
 
-// SumByFilter ...
-func (r *ServiceRepoPG) SumByFilter(s domain.SumFilterService) (domain.SumResult, error) {
+// listFilter returns the WHERE conditions of the ListFilterService filters.
+func listFilter(s domain.ListFilterService) ([]any, []string) {
 	var (
 		args   []any
 		values []string
 	)
+	if s.Name != "" {
+		args = append(args, likeContains(s.Name))
+		values = append(values, fmt.Sprintf("service_name ILIKE $%d ESCAPE '\\'", len(args)))
+	}
+	if s.Price > 0 {
+		args = append(args, s.Price)
+		values = append(values, fmt.Sprintf("service_price=$%d", len(args)))
+	}
+	if s.Uuid != nil {
+		args = append(args, s.Uuid.String())
+		values = append(values, fmt.Sprintf("service_uuid=$%d", len(args)))
+	}
+	return overlapFilter(args, values, s.From, s.To)
+}
+
+// likeEscaper quotes the LIKE wildcards, ESCAPE '\' must follow the pattern.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// likeContains matches name literally anywhere, like strings.Contains in the memory repository.
+func likeContains(name string) string {
+	return "%" + likeEscaper.Replace(name) + "%"
+}
+
+// listSort defaults the sort of s and returns the SQL type of its column.
+func listSort(s *domain.ListFilterService) string {
+	typ, ok := sortColumns[s.SortBy]
+	if !ok {
+		s.SortBy, typ = "service_created_at", "date"
+	}
+	if s.SortDir != "asc" {
+		s.SortDir = "desc"
+	}
+	return typ
+}
+
+// where ...
+func where(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(values, " AND ") + "\n"
+}
+
+// orderBy breaks ties by id so paging and iteration are stable.
+func orderBy(col, dir string) string {
+	return fmt.Sprintf("ORDER BY %s %s, service_id %s\n", sortKey(col), dir, dir)
+}
+
+// sortKey orders text by bytes, as the memory repository does, instead of the
+// database collation.
+func sortKey(col string) string {
+	if sortColumns[col] == "text" {
+		return col + ` COLLATE "C"`
+	}
+	return col
+}
+
+// IterateByFilter streams the rows of one query. QueryTimeout does not apply,
+// ctx bounds the whole iteration.
+func (r *ServiceRepoPG) IterateByFilter(ctx context.Context, s domain.ListFilterService) iter.Seq2[*domain.Service, error] {
+	return func(yield func(*domain.Service, error) bool) {
+		log := logging.FromContext(ctx)
+		ctx, span := r.startSpan(ctx, "IterateByFilter")
+		defer span.End()
+
+		args, values := listFilter(s)
+		listSort(&s)
+		sql := "SELECT " + serviceColumns + "\nFROM service_list\n" + where(values) + orderBy(s.SortBy, s.SortDir)
+		rows, err := r.conn().QueryContext(ctx, statement(ctx, sql), args...)
+		if err != nil {
+			log.Error("IterateByFilter Query error", "err", err)
+			yield(nil, mapPQError(ctx, err))
+			return
+		}
+		defer rows.Close()
+
+		n := 0
+		for rows.Next() {
+			var in repoService
+			if err := in.scan(rows); err != nil {
+				log.Error("IterateByFilter Scan error", "err", err)
+				yield(nil, err)
+				return
+			}
+			n++
+			if !yield(in.toService(), nil) {
+				return
+			}
+		}
+		if err := rows.Err(); err != nil {
+			log.Error("IterateByFilter Err error", "err", err)
+			yield(nil, mapPQError(ctx, err))
+			return
+		}
+		log.Debug("IterateByFilter done", "rows", n)
+	}
+}
+
+// count ...
+func (r *ServiceRepoPG) count(ctx context.Context, values []string, args []any) (int, error) {
+	log := logging.FromContext(ctx)
+	ctx, span := r.startSpan(ctx, "count")
+	defer span.End()
+	sql := "SELECT COUNT(*) FROM service_list\n" + where(values)
+	var n int
+	if err := r.conn().QueryRowContext(ctx, statement(ctx, sql), args...).Scan(&n); err != nil {
+		log.Error("count Query error", "err", err)
+		return 0, mapPQError(ctx, err)
+	}
+	return n, nil
+}
+
+// overlapFilter keeps rows whose active interval intersects [from, to].
+func overlapFilter(args []any, values []string, from, to *time.Time) ([]any, []string) {
+	if from != nil {
+		args = append(args, from)
+		values = append(values, fmt.Sprintf("(service_ended_at IS NULL OR service_ended_at>=$%d)", len(args)))
+	}
+	if to != nil {
+		args = append(args, to)
+		values = append(values, fmt.Sprintf("service_created_at<=$%d", len(args)))
+	}
+	return args, values
+}
+
+// billedMonths counts the months between the lo and hi columns of activeQuery.
+const billedMonths = `((EXTRACT(YEAR FROM hi) - EXTRACT(YEAR FROM lo)) * 12 + EXTRACT(MONTH FROM hi) - EXTRACT(MONTH FROM lo) + 1)`
+
+// groupColumns ...
+var groupColumns = map[string]string{
+	domain.GroupByName: "service_name",
+	domain.GroupByUser: "service_uuid",
+}
+
+// activeQuery selects filtered services with the billed window bounds lo and hi.
+func activeQuery(s domain.SumFilterService) (string, []any) {
+	var values []string
+	args := []any{s.From, s.PeriodEnd(time.Now())}
 	base := `
-SELECT COALESCE(SUM(service_price), 0)
+SELECT service_name, service_uuid, service_price,
+	GREATEST(service_created_at, $1::date) AS lo,
+	LEAST(service_ended_at, $2::date) AS hi
 FROM service_list
 `
 
 	if s.Name != "" {
-		args = append(args, "%"+s.Name+"%")
-		values = append(values, fmt.Sprintf("service_name ILIKE $%d", len(args)))
+		args = append(args, likeContains(s.Name))
+		values = append(values, fmt.Sprintf("service_name ILIKE $%d ESCAPE '\\'", len(args)))
 	}
 	if s.Uuid != nil {
 		args = append(args, s.Uuid.String())
 		values = append(values, fmt.Sprintf("service_uuid=$%d", len(args)))
 	}
-	if s.FromStartDate != nil {
-		args = append(args, s.FromStartDate)
-		values = append(values, fmt.Sprintf("service_created_at>=$%d", len(args)))
-	}
-	if s.ToStartDate != nil {
-		args = append(args, s.ToStartDate)
-		values = append(values, fmt.Sprintf("service_created_at<=$%d", len(args)))
-	}
+	args, values = overlapFilter(args, values, s.From, s.To)
 
 	var where string
 	if len(values) > 0 {
 		where = "WHERE " + strings.Join(values, " AND ") + "\n"
 	}
-	sql := base + where
+	return base + where, args
+}
+
+// SumByFilter ...
+func (r *ServiceRepoPG) SumByFilter(ctx context.Context, s domain.SumFilterService) (domain.SumResult, error) {
+	log := logging.FromContext(ctx)
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+	ctx, span := r.startSpan(ctx, "SumByFilter")
+	defer span.End()
+
+	inner, args := activeQuery(s)
+	sql := "SELECT COALESCE(SUM(service_price * " + billedMonths + "), 0)::bigint\nFROM (" + inner + ") AS active\nWHERE hi >= lo\n"
+
+	var total int64
+	if err := r.conn().QueryRowContext(ctx, statement(ctx, sql), args...).Scan(&total); err != nil {
+		log.Error("SumByFilter Query error", "err", err)
+		return domain.SumResult{}, mapPQError(ctx, err)
+	}
+
+	log.Debug("SumByFilter done", "total", total)
+	return domain.SumResult{Total: int(total)}, nil
+}
+
+// SumByGroup ...
+func (r *ServiceRepoPG) SumByGroup(ctx context.Context, s domain.SumFilterService) ([]domain.GroupSum, error) {
+	log := logging.FromContext(ctx)
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+	ctx, span := r.startSpan(ctx, "SumByGroup")
+	defer span.End()
+
+	col, ok := groupColumns[s.GroupBy]
+	if !ok {
+		return nil, fmt.Errorf("%w: group_by %q", domain.ErrValidation, s.GroupBy)
+	}
+	inner, args := activeQuery(s)
+	sql := "SELECT " + col + "::text, SUM(service_price * " + billedMonths + ")::bigint AS total, COUNT(*)\n" +
+		"FROM (" + inner + ") AS active\nWHERE hi >= lo\n" +
+		"GROUP BY " + col + "\nORDER BY total DESC, " + col + "::text COLLATE \"C\"\n"
+
+	rows, err := r.conn().QueryContext(ctx, statement(ctx, sql), args...)
+	if err != nil {
+		log.Error("SumByGroup Query error", "err", err)
+		return nil, mapPQError(ctx, err)
+	}
+	defer rows.Close()
+
+	out := []domain.GroupSum{}
+	for rows.Next() {
+		var g domain.GroupSum
+		if err := rows.Scan(&g.Key, &g.Total, &g.Count); err != nil {
+			log.Error("SumByGroup Scan error", "err", err)
+			return nil, err
+		}
+		out = append(out, g)
+	}
+	if err := rows.Err(); err != nil {
+		log.Error("SumByGroup Err error", "err", err)
+		return nil, mapPQError(ctx, err)
+	}
+
+	log.Debug("SumByGroup done", "groups", len(out))
+	return out, nil
+}
 
-	rows, err := r.db.Query(sql, args...)
+// SumByMonth ...
+func (r *ServiceRepoPG) SumByMonth(ctx context.Context, s domain.SumFilterService) ([]domain.MonthSum, error) {
+	log := logging.FromContext(ctx)
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+	ctx, span := r.startSpan(ctx, "SumByMonth")
+	defer span.End()
+
+	args := []any{s.From, s.PeriodEnd(time.Now())}
+	values := []string{
+		"s.service_created_at <= m",
+		"(s.service_ended_at IS NULL OR s.service_ended_at >= m)",
+	}
+	if s.Name != "" {
+		args = append(args, likeContains(s.Name))
+		values = append(values, fmt.Sprintf("s.service_name ILIKE $%d ESCAPE '\\'", len(args)))
+	}
+	if s.Uuid != nil {
+		args = append(args, s.Uuid.String())
+		values = append(values, fmt.Sprintf("s.service_uuid=$%d", len(args)))
+	}
+	sql := `
+SELECT to_char(m, 'MM-YYYY'), COALESCE(SUM(s.service_price), 0)::bigint, COUNT(s.service_id)
+FROM generate_series($1::timestamp, $2::timestamp, interval '1 month') AS m
+LEFT JOIN service_list s ON ` + strings.Join(values, " AND ") + `
+GROUP BY m
+ORDER BY m
+`
+
+	rows, err := r.conn().QueryContext(ctx, statement(ctx, sql), args...)
 	if err != nil {
-		slog.Error("SumByFilter Query error", "err", err)
-		return domain.SumResult{}, err
+		log.Error("SumByMonth Query error", "err", err)
+		return nil, mapPQError(ctx, err)
 	}
 	defer rows.Close()
 
-	var total domain.SumResult
+	out := []domain.MonthSum{}
 	for rows.Next() {
-		var rowPrice int
-		if err := rows.Scan(&rowPrice); err != nil {
-			slog.Error("SumByFilter Scan error", "err", err)
-			return domain.SumResult{}, err
+		var ms domain.MonthSum
+		if err := rows.Scan(&ms.Month, &ms.Total, &ms.Count); err != nil {
+			log.Error("SumByMonth Scan error", "err", err)
+			return nil, err
 		}
-		total.Total += rowPrice
+		out = append(out, ms)
+	}
+	if err := rows.Err(); err != nil {
+		log.Error("SumByMonth Err error", "err", err)
+		return nil, mapPQError(ctx, err)
 	}
 
-	slog.Debug("SumByFilter done", "total", total)
-	return total, nil
+	log.Debug("SumByMonth done", "months", len(out))
+	return out, nil
 }
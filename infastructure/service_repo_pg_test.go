@@ -1,29 +1,60 @@
 package infastructure
 
 import (
-	"log"
+	"context"
+	"os"
 	"testing"
 	"time"
 
 	"github.com/animans/REST-API-test-task/domain"
+	"github.com/animans/REST-API-test-task/domain/repotest"
 	"github.com/google/uuid"
 )
 
 func TestOpen(t *testing.T) {
-	s := NewServiceRepoPG()
+	if os.Getenv("DATABASE_URL") == "" {
+		t.Skip("DATABASE_URL not set")
+	}
+	s := NewServiceRepoPG(os.Getenv("DATABASE_URL"))
 	err := s.Open()
 	if err != nil {
-		log.Fatal(err)
+		t.Fatal(err)
 	}
 	s.Close()
 }
 
 func TestSave(t *testing.T) {
-	s := NewServiceRepoPG()
-	s.Open()
+	if os.Getenv("DATABASE_URL") == "" {
+		t.Skip("DATABASE_URL not set")
+	}
+	s := NewServiceRepoPG(os.Getenv("DATABASE_URL"))
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
 
 	uuid, _ := uuid.NewRandom()
 	ser := domain.NewService("Yandex Plus", 400, uuid, time.Now())
-	s.Save(ser)
-	s.Close()
+	if _, err := s.Save(context.Background(), ser); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestPGConformance runs the shared suite against DATABASE_URL.
+// The service_list table is truncated, so never point it at real data.
+func TestPGConformance(t *testing.T) {
+	if os.Getenv("DATABASE_URL") == "" {
+		t.Skip("DATABASE_URL not set")
+	}
+	repotest.Run(t, func(t *testing.T) domain.ServiceRepository {
+		r := NewServiceRepoPG(os.Getenv("DATABASE_URL"))
+		if err := r.Open(); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { r.Close() })
+		if _, err := r.db.Exec("TRUNCATE service_list RESTART IDENTITY"); err != nil {
+			t.Fatal(err)
+		}
+		return r
+	})
 }
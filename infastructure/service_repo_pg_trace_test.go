@@ -0,0 +1,63 @@
+package infastructure
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TestPGSpans needs no database: the query fails to connect and the span records the error.
+func TestPGSpans(t *testing.T) {
+	db, err := sql.Open("postgres", "host=127.0.0.1 port=1 user=u dbname=d sslmode=disable connect_timeout=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	sr := tracetest.NewSpanRecorder()
+	r := NewServiceRepoPG("")
+	r.db = db
+	r.Tracer = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)).Tracer("test")
+
+	if _, err := r.GetByID(t.Context(), "7"); err == nil {
+		t.Fatal("expected connection error")
+	}
+
+	spans := sr.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("spans: got %d, want 1", len(spans))
+	}
+	s := spans[0]
+	if s.Name() != "ServiceRepoPG.GetByID" || s.SpanKind() != trace.SpanKindClient {
+		t.Fatalf("span: got %q kind %v", s.Name(), s.SpanKind())
+	}
+	var stmt string
+	for _, kv := range s.Attributes() {
+		if kv.Key == "db.query.text" {
+			stmt = kv.Value.AsString()
+		}
+	}
+	if !strings.HasPrefix(stmt, "SELECT service_id") || !strings.Contains(stmt, "service_id=$1") || strings.Contains(stmt, "7") {
+		t.Fatalf("db.query.text: %q", stmt)
+	}
+	if len(s.Events()) == 0 || s.Status().Code.String() != "Error" {
+		t.Fatalf("error not recorded: %+v %+v", s.Events(), s.Status())
+	}
+}
+
+func TestStatementMasksLiterals(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	ctx, span := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)).Tracer("test").Start(t.Context(), "q")
+	q := "SELECT to_char(m, 'MM-YYYY') FROM t WHERE name='it''s' AND id=$1"
+	if got := statement(ctx, q); got != q {
+		t.Fatalf("statement changed the query: %q", got)
+	}
+	span.End()
+	got := sr.Ended()[0].Attributes()[0].Value.AsString()
+	if got != "SELECT to_char(m, '?') FROM t WHERE name='?' AND id=$1" {
+		t.Fatalf("db.query.text: %q", got)
+	}
+}
@@ -1,14 +1,30 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"log/slog"
+	"net"
+	nethttp "net/http"
 	"os"
-	"strings"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/animans/REST-API-test-task/auth"
+	"github.com/animans/REST-API-test-task/config"
 	_ "github.com/animans/REST-API-test-task/docs"
+	"github.com/animans/REST-API-test-task/domain"
 	"github.com/animans/REST-API-test-task/http"
 	"github.com/animans/REST-API-test-task/infastructure"
-	"github.com/joho/godotenv"
+	"github.com/animans/REST-API-test-task/migrations"
+	"github.com/animans/REST-API-test-task/ratelimit"
+	"github.com/animans/REST-API-test-task/tracing"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"go.opentelemetry.io/otel"
 )
 
 // @title Subscriptions REST API
@@ -17,44 +33,201 @@ import (
 // @BasePath        /
 // @schemes         http
 // @host            localhost:8080
-
-// init ...
-func init() {
-	if err := godotenv.Load(); err != nil {
-		slog.Error("No .env file found", "err", err)
-	}
-}
+// @securityDefinitions.apikey BearerAuth
+// @in                         header
+// @name                       Authorization
+// @description                JWT (HS256 или RS256) в формате "Bearer <token>"; sub — UUID пользователя, роль admin даёт доступ ко всем подпискам
+// @securityDefinitions.apikey ApiKeyAuth
+// @in                         header
+// @name                       X-API-Key
+// @description                API ключ, выпущенный через /admin/api-keys; scopes read, write, admin
 
 // main ...
 func main() {
-	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
-		Level: logLevel(os.ExpandEnv("LOG_LEVEL")),
+	file := flag.String("config", "", "YAML or JSON config file (default $CONFIG_FILE)")
+	healthcheck := flag.Bool("healthcheck", false, "probe /readyz of the local server and exit")
+	flag.Usage = usage
+	flag.Parse()
+	name, args := "serve", flag.Args()
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(flag.CommandLine.Output(), "unknown command %q\n", name)
+		usage()
+		os.Exit(2)
+	}
+	cfg, err := config.Load(*file)
+	if err != nil {
+		slog.Error("config load failed", "err", err)
+		os.Exit(1)
+	}
+	if *healthcheck {
+		if err := probe(cfg.HTTP.Addr); err != nil {
+			slog.Error("healthcheck failed", "err", err)
+			os.Exit(1)
+		}
+		return
+	}
+	// Only serve logs to stdout, the other commands may write data there.
+	out := os.Stderr
+	if name == "serve" {
+		out = os.Stdout
+	}
+	logger := slog.New(slog.NewTextHandler(out, &slog.HandlerOptions{
+		Level: cfg.Level(),
 	}))
 	slog.SetDefault(logger)
+	slog.Info("config loaded", "config", cfg)
 
-	repo := infastructure.NewServiceRepoPG()
-	err := repo.Open()
-	if err != nil {
-		slog.Error("repo open failed", "err", err)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := cmd.run(ctx, cfg, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		slog.Error(name+" failed", "err", err)
 		os.Exit(1)
 	}
-	defer repo.Close()
-	api := http.NewHandlers(repo)
-	if err := api.Start(); err != nil {
-		slog.Error("api start err", "err", err)
-		os.Exit(1)
+}
+
+// run serves the API until ctx is done and closes the repository on the way out.
+func run(ctx context.Context, cfg config.Config) error {
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing)
+	if err != nil {
+		return fmt.Errorf("tracing setup: %w", err)
+	}
+	defer func() {
+		sctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(sctx); err != nil {
+			slog.Error("tracing shutdown failed", "err", err)
+		}
+	}()
+
+	repo, err := openStorage(cfg.Storage)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := repo.Close(); err != nil {
+			slog.Error("repo close failed", "err", err)
+		}
+	}()
+	if pg, ok := repo.(*infastructure.ServiceRepoPG); ok && cfg.Storage.MigrateOnStart {
+		runner, err := migrations.NewRunner(pg.DB(), migrations.FS)
+		if err != nil {
+			return err
+		}
+		if err := runner.Up(ctx); err != nil {
+			return fmt.Errorf("migrate on start: %w", err)
+		}
+	}
+	if cfg.HTTP.CursorSecret == "" {
+		slog.Warn("CURSOR_SECRET not set, cursors are valid for this process only")
+	}
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	api := http.NewHandlers(infastructure.NewServiceRepoInstrumented(repo, reg), cfg.HTTP.CursorSecret)
+	api.ReadyTimeout = cfg.HTTP.ReadyTimeout
+	api.MaxImportBytes = int64(cfg.HTTP.MaxImportBytes)
+	api.ExportTimeout = cfg.HTTP.ExportTimeout
+	api.Logger = slog.Default()
+	if cfg.Tracing.Enabled {
+		api.TracerProvider = otel.GetTracerProvider()
+	}
+	api.Metrics = http.NewMetrics(reg)
+	if cfg.Auth.Enabled {
+		var chain auth.Chain
+		if cfg.Auth.JWT() {
+			jwtAuth, err := auth.NewJWTAuthenticator(cfg.Auth)
+			if err != nil {
+				return fmt.Errorf("auth setup: %w", err)
+			}
+			chain = append(chain, jwtAuth)
+		}
+		if cfg.Auth.APIKeys {
+			api.APIKeys = newAPIKeyStorage(repo, cfg.Storage)
+			chain = append(chain, auth.NewAPIKeyAuthenticator(api.APIKeys))
+		}
+		api.Auth = chain
+	} else {
+		slog.Warn("auth disabled, every caller has full access")
+	}
+	if cfg.RateLimit.Enabled {
+		api.RateLimiter = ratelimit.New(ratelimit.NewMemoryStore(), cfg.RateLimit)
+	}
+	if pg, ok := repo.(*infastructure.ServiceRepoPG); ok {
+		reg.MustRegister(collectors.NewDBStatsCollector(pg.DB(), "service"))
+		api.SchemaVersion = migrations.Latest()
+	}
+	return api.Start(ctx, serverOptions(cfg.HTTP))
+}
+
+// probe is used as the container healthcheck, the runtime image has no curl.
+func probe(addr string) error {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "127.0.0.1"
+	}
+	client := nethttp.Client{Timeout: 3 * time.Second}
+	resp, err := client.Get("http://" + net.JoinHostPort(host, port) + "/readyz")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != nethttp.StatusOK {
+		return fmt.Errorf("readyz status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// serverOptions ...
+func serverOptions(c config.HTTP) http.ServerOptions {
+	return http.ServerOptions{
+		Addr:              c.Addr,
+		ReadTimeout:       c.ReadTimeout,
+		ReadHeaderTimeout: c.ReadHeaderTimeout,
+		WriteTimeout:      c.WriteTimeout,
+		IdleTimeout:       c.IdleTimeout,
+		MaxHeaderBytes:    c.MaxHeaderBytes,
+		ShutdownTimeout:   c.ShutdownTimeout,
 	}
 }
 
-func logLevel(s string) slog.Level {
-	switch strings.ToLower(s) {
-	case "debug":
-		return slog.LevelDebug
-	case "warn", "warning":
-		return slog.LevelWarn
-	case "error":
-		return slog.LevelError
+// storage is a ServiceRepository with a connection lifecycle.
+type storage interface {
+	domain.ServiceRepository
+	Open() error
+	Close() error
+}
+
+// newStorage selects the repository backend.
+func newStorage(c config.Storage) storage {
+	switch c.Kind {
+	case config.StorageMemory:
+		slog.Info("using in-memory storage")
+		return infastructure.NewServiceRepoMem()
 	default:
-		return slog.LevelInfo
+		repo := infastructure.NewServiceRepoPG(c.DatabaseURL)
+		repo.QueryTimeout = c.QueryTimeout
+		return repo
+	}
+}
+
+// newAPIKeyStorage keeps API keys next to the services, sharing the Postgres pool.
+func newAPIKeyStorage(repo storage, c config.Storage) domain.APIKeyRepository {
+	if pg, ok := repo.(*infastructure.ServiceRepoPG); ok {
+		keys := infastructure.NewAPIKeyRepoPG(pg.DB())
+		keys.QueryTimeout = c.QueryTimeout
+		return keys
 	}
+	return infastructure.NewAPIKeyRepoMem()
 }
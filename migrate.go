@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/animans/REST-API-test-task/config"
+	"github.com/animans/REST-API-test-task/infastructure"
+	"github.com/animans/REST-API-test-task/migrations"
+)
+
+// migrateUsage ...
+const migrateUsage = "usage: migrate up | down [steps] | goto <version> | force <version> | status"
+
+// runMigrate applies the embedded migrations to the configured database.
+func runMigrate(ctx context.Context, cfg config.Config, args []string) error {
+	if cfg.Storage.Kind != config.StoragePostgres {
+		return errors.New("migrate needs postgres storage")
+	}
+	cmd := "up"
+	if len(args) > 0 {
+		cmd = args[0]
+	}
+	if !slices.Contains([]string{"up", "down", "goto", "force", "status"}, cmd) {
+		return errors.New(migrateUsage)
+	}
+	arg := func() (int64, error) {
+		if len(args) != 2 {
+			return 0, errors.New(migrateUsage)
+		}
+		return strconv.ParseInt(args[1], 10, 64)
+	}
+
+	repo := infastructure.NewServiceRepoPG(cfg.Storage.DatabaseURL)
+	if err := repo.Open(); err != nil {
+		return fmt.Errorf("repo open: %w", err)
+	}
+	defer repo.Close()
+	runner, err := migrations.NewRunner(repo.DB(), migrations.FS)
+	if err != nil {
+		return err
+	}
+
+	switch cmd {
+	case "up":
+		return runner.Up(ctx)
+	case "down":
+		steps := int64(1)
+		if len(args) > 1 {
+			if steps, err = arg(); err != nil {
+				return err
+			}
+		}
+		return runner.Down(ctx, int(steps))
+	case "goto":
+		v, err := arg()
+		if err != nil {
+			return err
+		}
+		return runner.Goto(ctx, v)
+	case "force":
+		v, err := arg()
+		if err != nil {
+			return err
+		}
+		return runner.Force(ctx, v)
+	case "status":
+		st, err := runner.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "version %d, dirty %t\n\n", st.Version, st.Dirty)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED")
+		for _, m := range st.Migrations {
+			fmt.Fprintf(w, "%d\t%s\t%t\n", m.Version, m.Name, m.Applied)
+		}
+		return w.Flush()
+	default:
+		return errors.New(migrateUsage)
+	}
+}
@@ -1 +1 @@
-DROP TABLE service_list
\ No newline at end of file
+DROP TABLE IF EXISTS service_list;
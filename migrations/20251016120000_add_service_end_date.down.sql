@@ -0,0 +1,3 @@
+ALTER TABLE service_list DROP CONSTRAINT IF EXISTS service_list_end_after_start;
+
+ALTER TABLE service_list DROP COLUMN IF EXISTS service_ended_at;
@@ -0,0 +1,4 @@
+ALTER TABLE service_list ADD COLUMN service_ended_at DATE;
+
+ALTER TABLE service_list ADD CONSTRAINT service_list_end_after_start
+	CHECK (service_ended_at IS NULL OR service_ended_at >= service_created_at);
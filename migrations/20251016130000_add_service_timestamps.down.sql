@@ -0,0 +1,3 @@
+ALTER TABLE service_list
+	DROP COLUMN IF EXISTS service_updated_at,
+	DROP COLUMN IF EXISTS service_inserted_at;
@@ -0,0 +1,3 @@
+ALTER TABLE service_list
+	ADD COLUMN service_inserted_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	ADD COLUMN service_updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
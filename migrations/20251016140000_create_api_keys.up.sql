@@ -0,0 +1,12 @@
+CREATE TABLE api_keys (
+	key_id UUID PRIMARY KEY,
+	key_name VARCHAR(128) NOT NULL,
+	key_prefix VARCHAR(16) NOT NULL,
+	key_hash CHAR(64) NOT NULL UNIQUE,
+	key_user_uuid UUID,
+	key_scopes TEXT[] NOT NULL CHECK (cardinality(key_scopes) > 0 AND key_scopes <@ ARRAY['read', 'write', 'admin']),
+	key_expires_at TIMESTAMPTZ,
+	key_last_used_at TIMESTAMPTZ,
+	key_revoked_at TIMESTAMPTZ,
+	key_created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
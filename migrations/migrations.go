@@ -0,0 +1,28 @@
+// Package migrations embeds the SQL migrations applied to the service database.
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+	"strconv"
+	"strings"
+)
+
+// FS holds the golang-migrate style {version}_{name}.{up|down}.sql files.
+//
+//go:embed *.sql
+var FS embed.FS
+
+// Latest returns the highest migration version in FS.
+func Latest() int64 {
+	var latest int64
+	files, _ := fs.Glob(FS, "*.up.sql")
+	for _, f := range files {
+		v, _, _ := strings.Cut(f, "_")
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err == nil && n > latest {
+			latest = n
+		}
+	}
+	return latest
+}
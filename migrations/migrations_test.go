@@ -0,0 +1,9 @@
+package migrations
+
+import "testing"
+
+func TestLatest(t *testing.T) {
+	if got := Latest(); got < 20251016130000 {
+		t.Fatalf("Latest: got %d", got)
+	}
+}
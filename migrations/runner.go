@@ -0,0 +1,322 @@
+package migrations
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io/fs"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/animans/REST-API-test-task/logging"
+)
+
+// Migration is one {version}_{name}.{up|down}.sql pair.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Load reads the migrations in fsys ordered by version; every version needs both files.
+func Load(fsys fs.FS) ([]Migration, error) {
+	files, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[int64]*Migration{}
+	for _, f := range files {
+		base, up := strings.CutSuffix(f, ".up.sql")
+		if !up {
+			var down bool
+			if base, down = strings.CutSuffix(f, ".down.sql"); !down {
+				return nil, fmt.Errorf("migration %s: want {version}_{name}.up.sql or .down.sql", f)
+			}
+		}
+		v, name, _ := strings.Cut(base, "_")
+		version, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s: invalid version %q", f, v)
+		}
+		b, err := fs.ReadFile(fsys, f)
+		if err != nil {
+			return nil, err
+		}
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		} else if m.Name != name {
+			return nil, fmt.Errorf("migration %d: names %q and %q differ", version, m.Name, name)
+		}
+		if up {
+			m.Up = string(b)
+		} else {
+			m.Down = string(b)
+		}
+	}
+
+	out := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if strings.TrimSpace(m.Up) == "" || strings.TrimSpace(m.Down) == "" {
+			return nil, fmt.Errorf("migration %d_%s: up and down must both be non-empty", m.Version, m.Name)
+		}
+		out = append(out, *m)
+	}
+	slices.SortFunc(out, func(a, b Migration) int { return cmp.Compare(a.Version, b.Version) })
+	return out, nil
+}
+
+// ErrDirty is returned while a previous run left the schema half migrated; see Runner.Force.
+var ErrDirty = errors.New("dirty database")
+
+// Runner applies migrations to Postgres. It keeps golang-migrate's
+// schema_migrations table (one row: version, dirty) and advisory lock,
+// so either tool can take over from the other.
+type Runner struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// NewRunner ...
+func NewRunner(db *sql.DB, fsys fs.FS) (*Runner, error) {
+	ms, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Runner{db: db, migrations: ms}, nil
+}
+
+// MigrationStatus ...
+type MigrationStatus struct {
+	Version int64  `json:"version"`
+	Name    string `json:"name"`
+	Applied bool   `json:"applied"`
+}
+
+// Status is the schema version, zero when nothing was applied, and every known migration.
+type Status struct {
+	Version    int64             `json:"version"`
+	Dirty      bool              `json:"dirty"`
+	Migrations []MigrationStatus `json:"migrations"`
+}
+
+// Status ...
+func (r *Runner) Status(ctx context.Context) (Status, error) {
+	var st Status
+	err := r.locked(ctx, func(conn *sql.Conn, version int64, dirty bool) error {
+		st.Version, st.Dirty = version, dirty
+		for _, m := range r.migrations {
+			st.Migrations = append(st.Migrations, MigrationStatus{Version: m.Version, Name: m.Name, Applied: m.Version <= version})
+		}
+		return nil
+	})
+	return st, err
+}
+
+// Up applies every pending migration.
+func (r *Runner) Up(ctx context.Context) error {
+	if len(r.migrations) == 0 {
+		return nil
+	}
+	return r.Goto(ctx, r.migrations[len(r.migrations)-1].Version)
+}
+
+// Down rolls back the last steps migrations.
+func (r *Runner) Down(ctx context.Context, steps int) error {
+	if steps < 1 {
+		return fmt.Errorf("down: steps must be >= 1, got %d", steps)
+	}
+	return r.migrate(ctx, func(current int64) int64 {
+		if i := r.index(current); i-steps >= 0 {
+			return r.migrations[i-steps].Version
+		}
+		return 0
+	})
+}
+
+// Goto migrates up or down to version; zero rolls everything back.
+func (r *Runner) Goto(ctx context.Context, version int64) error {
+	if version != 0 && r.index(version) < 0 {
+		return fmt.Errorf("goto: unknown version %d", version)
+	}
+	return r.migrate(ctx, func(int64) int64 { return version })
+}
+
+// Force records version as applied and clean without running any SQL,
+// after a failed migration was repaired by hand. Zero clears the version.
+func (r *Runner) Force(ctx context.Context, version int64) error {
+	if version != 0 && r.index(version) < 0 {
+		return fmt.Errorf("force: unknown version %d", version)
+	}
+	return r.locked(ctx, func(conn *sql.Conn, _ int64, _ bool) error {
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+		if err := setVersion(ctx, tx, version); err != nil {
+			return err
+		}
+		logging.FromContext(ctx).Warn("migration version forced", "version", version)
+		return tx.Commit()
+	})
+}
+
+// index returns the position of version in r.migrations, -1 when unknown.
+func (r *Runner) index(version int64) int {
+	for i, m := range r.migrations {
+		if m.Version == version {
+			return i
+		}
+	}
+	return -1
+}
+
+// migrate moves from the current version to the one target picks, one transaction per migration.
+func (r *Runner) migrate(ctx context.Context, target func(current int64) int64) error {
+	return r.locked(ctx, func(conn *sql.Conn, current int64, dirty bool) error {
+		if dirty {
+			return fmt.Errorf("%w: version %d, fix it and run force", ErrDirty, current)
+		}
+		if current != 0 && r.index(current) < 0 {
+			return fmt.Errorf("database version %d is not in the embedded migrations", current)
+		}
+		to := target(current)
+		log := logging.FromContext(ctx)
+		if to == current {
+			log.Info("migrations up to date", "version", current)
+			return nil
+		}
+		for _, s := range r.plan(current, to) {
+			start := time.Now()
+			if err := r.apply(ctx, conn, s); err != nil {
+				return fmt.Errorf("migration %d_%s %s: %w", s.m.Version, s.m.Name, s.direction(), err)
+			}
+			log.Info("migration applied", "version", s.m.Version, "name", s.m.Name, "direction", s.direction(), "duration_ms", time.Since(start).Milliseconds())
+		}
+		return nil
+	})
+}
+
+// step runs m up, or down to the version before it.
+type step struct {
+	m    Migration
+	up   bool
+	prev int64
+}
+
+// direction ...
+func (s step) direction() string {
+	if s.up {
+		return "up"
+	}
+	return "down"
+}
+
+// plan lists the steps from current to target, both known versions or zero.
+func (r *Runner) plan(current, target int64) []step {
+	var steps []step
+	if target > current {
+		for _, m := range r.migrations {
+			if m.Version > current && m.Version <= target {
+				steps = append(steps, step{m: m, up: true})
+			}
+		}
+		return steps
+	}
+	for i := len(r.migrations) - 1; i >= 0; i-- {
+		m := r.migrations[i]
+		if m.Version <= target || m.Version > current {
+			continue
+		}
+		s := step{m: m}
+		if i > 0 {
+			s.prev = r.migrations[i-1].Version
+		}
+		steps = append(steps, s)
+	}
+	return steps
+}
+
+// apply runs one step and records the new version in the same transaction.
+func (r *Runner) apply(ctx context.Context, conn *sql.Conn, s step) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	query, version := s.m.Down, s.prev
+	if s.up {
+		query, version = s.m.Up, s.m.Version
+	}
+	if _, err := tx.ExecContext(ctx, query); err != nil {
+		return err
+	}
+	if err := setVersion(ctx, tx, version); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// setVersion replaces the single schema_migrations row; zero leaves the table empty.
+func setVersion(ctx context.Context, tx *sql.Tx, version int64) error {
+	if _, err := tx.ExecContext(ctx, "TRUNCATE schema_migrations"); err != nil {
+		return err
+	}
+	if version == 0 {
+		return nil
+	}
+	_, err := tx.ExecContext(ctx, "INSERT INTO schema_migrations (version, dirty) VALUES ($1, false)", version)
+	return err
+}
+
+// locked runs fn on one connection holding the migration lock; fn handles a dirty version.
+func (r *Runner) locked(ctx context.Context, fn func(conn *sql.Conn, version int64, dirty bool) error) error {
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var database, schema string
+	if err := conn.QueryRowContext(ctx, "SELECT current_database(), current_schema()").Scan(&database, &schema); err != nil {
+		return err
+	}
+	id := LockID(database, schema, "schema_migrations")
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", id); err != nil {
+		return fmt.Errorf("migration lock: %w", err)
+	}
+	defer func() {
+		// The context may be done already, the lock must be released anyway.
+		_, _ = conn.ExecContext(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1)", id)
+	}()
+
+	if _, err := conn.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS schema_migrations (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL)"); err != nil {
+		return err
+	}
+	var (
+		version int64
+		dirty   bool
+	)
+	err = conn.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	return fn(conn, version, dirty)
+}
+
+// lockSalt ...
+const lockSalt uint32 = 1486364155
+
+// LockID derives the advisory lock key the way golang-migrate's postgres driver does.
+func LockID(database, schema, table string) int64 {
+	sum := crc32.ChecksumIEEE([]byte(strings.Join([]string{schema, table, database}, "\x00")))
+	return int64(sum * lockSalt)
+}
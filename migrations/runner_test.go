@@ -0,0 +1,139 @@
+package migrations
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"testing"
+	"testing/fstest"
+
+	_ "github.com/lib/pq"
+)
+
+func TestLoad(t *testing.T) {
+	ms, err := Load(FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ms) == 0 || ms[len(ms)-1].Version != Latest() {
+		t.Fatalf("Load: %d migrations, last %+v", len(ms), ms[len(ms)-1])
+	}
+	for i := 1; i < len(ms); i++ {
+		if ms[i-1].Version >= ms[i].Version {
+			t.Fatalf("not sorted: %d before %d", ms[i-1].Version, ms[i].Version)
+		}
+	}
+
+	bad := map[string]fstest.MapFS{
+		"no down":    {"1_a.up.sql": {Data: []byte("SELECT 1")}},
+		"empty down": {"1_a.up.sql": {Data: []byte("SELECT 1")}, "1_a.down.sql": {Data: []byte(" \n")}},
+		"bad name":   {"a_b.up.sql": {Data: []byte("SELECT 1")}},
+		"bad suffix": {"1_a.sql": {Data: []byte("SELECT 1")}},
+		"names":      {"1_a.up.sql": {Data: []byte("SELECT 1")}, "1_b.down.sql": {Data: []byte("SELECT 1")}},
+	}
+	for name, fsys := range bad {
+		if _, err := Load(fsys); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestPlan(t *testing.T) {
+	r := &Runner{migrations: []Migration{{Version: 1}, {Version: 2}, {Version: 3}}}
+	versions := func(steps []step) (out []int64) {
+		for _, s := range steps {
+			out = append(out, s.m.Version, s.prev)
+		}
+		return out
+	}
+	tests := []struct {
+		current, target int64
+		up              bool
+		want            []int64
+	}{
+		{0, 3, true, []int64{1, 0, 2, 0, 3, 0}},
+		{1, 2, true, []int64{2, 0}},
+		{3, 1, false, []int64{3, 2, 2, 1}},
+		{2, 0, false, []int64{2, 1, 1, 0}},
+	}
+	for _, tt := range tests {
+		steps := r.plan(tt.current, tt.target)
+		got := versions(steps)
+		if len(got) != len(tt.want) {
+			t.Fatalf("%d->%d: got %v want %v", tt.current, tt.target, got, tt.want)
+		}
+		for i := range got {
+			if got[i] != tt.want[i] || steps[i/2].up != tt.up {
+				t.Fatalf("%d->%d: got %v want %v", tt.current, tt.target, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestLockID(t *testing.T) {
+	a := LockID("db", "public", "schema_migrations")
+	if a != LockID("db", "public", "schema_migrations") || a == LockID("other", "public", "schema_migrations") {
+		t.Fatal("LockID must be stable per database")
+	}
+}
+
+// TestRunnerPG drops every table of MIGRATIONS_DATABASE_URL, use a scratch database.
+func TestRunnerPG(t *testing.T) {
+	dsn, ok := os.LookupEnv("MIGRATIONS_DATABASE_URL")
+	if !ok {
+		t.Skip("MIGRATIONS_DATABASE_URL not set")
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	r, err := NewRunner(db, FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := t.Context()
+	status := func() Status {
+		t.Helper()
+		st, err := r.Status(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return st
+	}
+
+	if err := r.Goto(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if st := status(); st.Version != Latest() || st.Dirty || !st.Migrations[len(st.Migrations)-1].Applied {
+		t.Fatalf("after up: %+v", st)
+	}
+	if err := r.Down(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	if st := status(); st.Version != r.migrations[len(r.migrations)-3].Version {
+		t.Fatalf("after down 2: %+v", st)
+	}
+
+	if _, err := db.Exec("UPDATE schema_migrations SET dirty = true"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Up(ctx); !errors.Is(err, ErrDirty) {
+		t.Fatalf("dirty: got %v", err)
+	}
+	if err := r.Force(ctx, status().Version); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Goto(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+	if st := status(); st.Version != 0 {
+		t.Fatalf("after goto 0: %+v", st)
+	}
+}
@@ -0,0 +1,96 @@
+package ratelimit
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/animans/REST-API-test-task/config"
+)
+
+func TestMemoryStoreTake(t *testing.T) {
+	s := NewMemoryStore()
+	l := Limit{RPS: 2, Burst: 3}
+	now := time.Date(2025, time.October, 1, 12, 0, 0, 0, time.UTC)
+
+	for i := 2; i >= 0; i-- {
+		res, _ := s.Take(t.Context(), "a", l, now)
+		if !res.Allowed || res.Remaining != i || res.Limit != 3 {
+			t.Fatalf("take %d: %+v", i, res)
+		}
+	}
+	res, _ := s.Take(t.Context(), "a", l, now)
+	if res.Allowed || res.RetryAfter != 500*time.Millisecond || res.Reset != 1500*time.Millisecond {
+		t.Fatalf("empty bucket: %+v", res)
+	}
+	if res, _ := s.Take(t.Context(), "b", l, now); !res.Allowed {
+		t.Fatalf("other key shares the bucket: %+v", res)
+	}
+
+	now = now.Add(500 * time.Millisecond)
+	if res, _ := s.Take(t.Context(), "a", l, now); !res.Allowed || res.Remaining != 0 {
+		t.Fatalf("after refill: %+v", res)
+	}
+	now = now.Add(time.Hour)
+	if res, _ := s.Take(t.Context(), "a", l, now); !res.Allowed || res.Remaining != 2 {
+		t.Fatalf("refill is capped at burst: %+v", res)
+	}
+}
+
+func TestMemoryStoreSweep(t *testing.T) {
+	s := NewMemoryStore()
+	l := Limit{RPS: 1, Burst: 1}
+	now := time.Now()
+	for _, key := range []string{"a", "b", "c"} {
+		_, _ = s.Take(t.Context(), key, l, now)
+	}
+	if s.Len() != 3 {
+		t.Fatalf("Len: got %d", s.Len())
+	}
+	_, _ = s.Take(t.Context(), "d", l, now.Add(2*s.SweepInterval))
+	if s.Len() != 1 {
+		t.Fatalf("sweep kept refilled buckets: %d", s.Len())
+	}
+}
+
+func TestMemoryStoreConcurrent(t *testing.T) {
+	s := NewMemoryStore()
+	l := Limit{RPS: 0.001, Burst: 50}
+	now := time.Now()
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		allowed int
+	)
+	for range 200 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if res, _ := s.Take(t.Context(), "k", l, now); res.Allowed {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if allowed != 50 {
+		t.Fatalf("allowed: got %d want 50", allowed)
+	}
+}
+
+func TestLimiterRoutes(t *testing.T) {
+	l := New(NewMemoryStore(), config.RateLimit{
+		Default: config.Limit{RPS: 1, Burst: 2},
+		Routes:  map[string]config.Limit{"/service/summary": {RPS: 1, Burst: 1}},
+	})
+	if res, _ := l.Allow(t.Context(), "/service/summary", "ip:1"); !res.Allowed || res.Limit != 1 {
+		t.Fatalf("summary: %+v", res)
+	}
+	if res, _ := l.Allow(t.Context(), "/service/summary", "ip:1"); res.Allowed {
+		t.Fatalf("summary over limit: %+v", res)
+	}
+	if res, _ := l.Allow(t.Context(), "/service", "ip:1"); !res.Allowed || res.Limit != 2 {
+		t.Fatalf("routes must not share buckets: %+v", res)
+	}
+}
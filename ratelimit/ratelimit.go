@@ -0,0 +1,95 @@
+// Package ratelimit implements per-client token buckets behind a pluggable Store.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/animans/REST-API-test-task/config"
+)
+
+// Limit is a token bucket refilled at RPS tokens per second up to Burst.
+type Limit struct {
+	RPS   float64
+	Burst int
+}
+
+// Result describes the bucket after a Take.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is the time until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is the time until the next token, zero when Allowed.
+	RetryAfter time.Duration
+}
+
+// Store keeps the buckets. Take removes one token from the bucket of key if it
+// has one. Implementations must be safe for concurrent use; a shared store
+// (e.g. Redis) lets several instances enforce one limit.
+type Store interface {
+	Take(ctx context.Context, key string, l Limit, now time.Time) (Result, error)
+}
+
+// Limiter picks the limit of a route and takes from the client's bucket.
+type Limiter struct {
+	Store   Store
+	Default Limit
+	// Auth is the per-address limit checked before authentication.
+	Auth Limit
+	// Routes overrides Default per mux route template.
+	Routes map[string]Limit
+	// TrustProxy keys anonymous clients by X-Forwarded-For.
+	TrustProxy bool
+	now        func() time.Time
+}
+
+// New builds a Limiter from c.
+func New(store Store, c config.RateLimit) *Limiter {
+	l := &Limiter{
+		Store:      store,
+		Default:    Limit(c.Default),
+		Auth:       Limit(c.Auth),
+		Routes:     make(map[string]Limit, len(c.Routes)),
+		TrustProxy: c.TrustProxy,
+		now:        time.Now,
+	}
+	for route, rl := range c.Routes {
+		l.Routes[route] = Limit(rl)
+	}
+	return l
+}
+
+// Allow takes a token for client on route; every route has its own bucket.
+func (l *Limiter) Allow(ctx context.Context, route, client string) (Result, error) {
+	limit, ok := l.Routes[route]
+	if !ok {
+		limit = l.Default
+	}
+	return l.take(ctx, route+"|"+client, limit)
+}
+
+// AllowAuth takes a token for client from its bucket in front of authentication,
+// shared by all routes.
+func (l *Limiter) AllowAuth(ctx context.Context, client string) (Result, error) {
+	return l.take(ctx, "auth|"+client, l.Auth)
+}
+
+// take ...
+func (l *Limiter) take(ctx context.Context, key string, limit Limit) (Result, error) {
+	now := time.Now
+	if l.now != nil {
+		now = l.now
+	}
+	return l.Store.Take(ctx, key, limit, now())
+}
+
+// seconds converts a token deficit at rps into a duration.
+func seconds(tokens, rps float64) time.Duration {
+	if tokens <= 0 {
+		return 0
+	}
+	return time.Duration(math.Ceil(tokens / rps * float64(time.Second)))
+}
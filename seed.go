@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+
+	"github.com/animans/REST-API-test-task/config"
+	"github.com/animans/REST-API-test-task/domain"
+	"github.com/google/uuid"
+)
+
+// seedNames ...
+var seedNames = []string{"Yandex Plus", "Netflix", "Spotify", "GPT Plus", "Kinopoisk", "YouTube Premium", "Apple One", "Okko", "IVI", "VK Music"}
+
+// runSeed inserts random subscriptions spread over the last three years.
+func runSeed(ctx context.Context, cfg config.Config, args []string) error {
+	fs := flag.NewFlagSet("seed", flag.ContinueOnError)
+	n := fs.Int("n", 100, "number of subscriptions")
+	users := fs.Int("users", 10, "number of distinct users")
+	seed := fs.Uint64("seed", 0, "random seed for a reproducible dataset, 0 picks one")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *n < 1 || *users < 1 || fs.NArg() > 0 {
+		return errors.New("usage: seed [-n N] [-users N] [-seed N]")
+	}
+	if *seed == 0 {
+		*seed = rand.Uint64()
+	}
+
+	warnVolatile(cfg.Storage, "seed")
+	repo, err := openStorage(cfg.Storage)
+	if err != nil {
+		return err
+	}
+	defer repo.Close()
+
+	var key [32]byte
+	binary.LittleEndian.PutUint64(key[:], *seed)
+	src := rand.NewChaCha8(key)
+	rng := rand.New(src)
+	ids := make([]uuid.UUID, *users)
+	for i := range ids {
+		if ids[i], err = uuid.NewRandomFromReader(src); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now().UTC()
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for range *n {
+		start := month.AddDate(0, -rng.IntN(36), 0)
+		s := domain.NewService(seedNames[rng.IntN(len(seedNames))], (rng.IntN(40)+1)*50, ids[rng.IntN(len(ids))], start)
+		if rng.IntN(10) < 3 {
+			end := start.AddDate(0, rng.IntN(24), 0)
+			s.SetEndDate(&end)
+		}
+		if _, err := repo.Save(ctx, s); err != nil {
+			return err
+		}
+	}
+	slog.Info("seed done", "n", *n, "users", *users, "seed", *seed)
+	fmt.Printf("seeded %d subscriptions for %d users (seed %d)\n", *n, *users, *seed)
+	return nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/animans/REST-API-test-task/config"
+	"github.com/animans/REST-API-test-task/domain"
+)
+
+// runSummary prints SumByFilter, or SumByGroup with -group-by, like GET /service/summary.
+func runSummary(ctx context.Context, cfg config.Config, args []string) error {
+	fs := flag.NewFlagSet("summary", flag.ContinueOnError)
+	groupBy := fs.String("group-by", "", "service_name or user_id")
+	asJSON := fs.Bool("json", false, "print the result as JSON")
+	var filter filterFlags
+	filter.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return errors.New("usage: summary [-user id] [-name name] [-from MM-YYYY] [-to MM-YYYY] [-group-by service_name|user_id] [-json]")
+	}
+	var (
+		f   domain.SumFilterService
+		err error
+	)
+	if f.Name, f.Uuid, f.From, f.To, err = filter.parse(); err != nil {
+		return err
+	}
+	switch *groupBy {
+	case "", domain.GroupByName, domain.GroupByUser:
+		f.GroupBy = *groupBy
+	default:
+		return fmt.Errorf("-group-by %q: want service_name or user_id", *groupBy)
+	}
+
+	repo, err := openStorage(cfg.Storage)
+	if err != nil {
+		return err
+	}
+	defer repo.Close()
+
+	var out domain.SumResult
+	if f.GroupBy != "" {
+		out.Groups, err = repo.SumByGroup(ctx, f)
+		for _, g := range out.Groups {
+			out.Total += g.Total
+		}
+	} else {
+		out, err = repo.SumByFilter(ctx, f)
+	}
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	if len(out.Groups) > 0 {
+		fmt.Fprintln(w, "KEY\tTOTAL\tCOUNT")
+		for _, g := range out.Groups {
+			fmt.Fprintf(w, "%s\t%d\t%d\n", g.Key, g.Total, g.Count)
+		}
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintf(w, "total\t%d\n", out.Total)
+	return w.Flush()
+}
@@ -0,0 +1,61 @@
+// Package tracing configures the OpenTelemetry tracer provider.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/animans/REST-API-test-task/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+)
+
+// Propagator is the W3C trace-context and baggage propagator.
+func Propagator() propagation.TextMapPropagator {
+	return propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+}
+
+// Setup installs the global tracer provider and propagator when tracing is enabled.
+// The returned function flushes and stops the exporter.
+func Setup(ctx context.Context, c config.Tracing) (func(context.Context) error, error) {
+	if !c.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+	exp, err := newExporter(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(c.ServiceName))),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(c.SampleRatio))),
+	)
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(Propagator())
+	slog.Info("tracing enabled", "exporter", c.Exporter, "sample_ratio", c.SampleRatio)
+	return tp.Shutdown, nil
+}
+
+// newExporter ...
+func newExporter(ctx context.Context, c config.Tracing) (sdktrace.SpanExporter, error) {
+	switch c.Exporter {
+	case config.ExporterStdout:
+		return stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case config.ExporterOTLP:
+		var opts []otlptracehttp.Option
+		if c.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpoint(c.Endpoint))
+		}
+		if c.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		return otlptracehttp.New(ctx, opts...)
+	}
+	return nil, fmt.Errorf("unknown tracing exporter %q", c.Exporter)
+}
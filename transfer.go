@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/animans/REST-API-test-task/config"
+	"github.com/animans/REST-API-test-task/domain"
+	"github.com/animans/REST-API-test-task/transfer"
+)
+
+// formatFor picks the -format value, falling back to the file extension and then ndjson.
+func formatFor(format, file string) (string, error) {
+	if format == "" {
+		format = strings.TrimPrefix(filepath.Ext(file), ".")
+		if format != transfer.FormatCSV && format != transfer.FormatXLSX {
+			format = transfer.FormatNDJSON
+		}
+	}
+	return transfer.ParseFormat(format)
+}
+
+// runImport saves every valid line of a file (or stdin) and reports the others.
+func runImport(ctx context.Context, cfg config.Config, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	format := fs.String("format", "", "ndjson or csv (default from the file extension)")
+	dryRun := fs.Bool("dry-run", false, "validate every line without saving")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		return errors.New("usage: import [-format ndjson|csv] [-dry-run] [file|-]")
+	}
+	file := fs.Arg(0)
+	f, err := formatFor(*format, file)
+	if err != nil {
+		return err
+	}
+	if f == transfer.FormatXLSX {
+		return errors.New("xlsx is export only, save the sheet as csv")
+	}
+	var in io.Reader = os.Stdin
+	if file != "" && file != "-" {
+		fh, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer fh.Close()
+		in = fh
+	}
+	r, err := transfer.NewReader(in, f)
+	if err != nil {
+		return err
+	}
+
+	if !*dryRun {
+		warnVolatile(cfg.Storage, "import")
+	}
+	repo, err := openStorage(cfg.Storage)
+	if err != nil {
+		return err
+	}
+	defer repo.Close()
+
+	rep, err := transfer.Import(ctx, repo, r, transfer.ImportOptions{DryRun: *dryRun})
+	for _, l := range rep.Lines {
+		if l.Error == "" {
+			continue
+		}
+		msg := l.Error
+		for _, fe := range l.Fields {
+			msg += "; " + fe.Field + ": " + fe.Message
+		}
+		fmt.Fprintf(os.Stderr, "line %d: %s\n", l.Line, msg)
+	}
+	if rep.DryRun {
+		fmt.Printf("%d of %d lines valid, %d failed\n", rep.Valid, rep.Total, rep.Failed)
+	} else {
+		fmt.Printf("imported %d of %d lines, %d failed\n", rep.Imported, rep.Total, rep.Failed)
+	}
+	if err != nil {
+		return err
+	}
+	if rep.Failed > 0 {
+		return fmt.Errorf("%d lines failed", rep.Failed)
+	}
+	return nil
+}
+
+// runExport writes the matching subscriptions to a file or stdout.
+func runExport(ctx context.Context, cfg config.Config, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := fs.String("format", "", "ndjson, csv or xlsx (default from -o, then ndjson)")
+	output := fs.String("o", "", "output file (default stdout)")
+	var filter filterFlags
+	filter.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return errors.New("usage: export [-format ndjson|csv|xlsx] [-o file] [-user id] [-name name] [-from MM-YYYY] [-to MM-YYYY]")
+	}
+	f, err := formatFor(*format, *output)
+	if err != nil {
+		return err
+	}
+	var lf domain.ListFilterService
+	if lf.Name, lf.Uuid, lf.From, lf.To, err = filter.parse(); err != nil {
+		return err
+	}
+
+	repo, err := openStorage(cfg.Storage)
+	if err != nil {
+		return err
+	}
+	defer repo.Close()
+
+	var out io.Writer = os.Stdout
+	if *output != "" && *output != "-" {
+		fh, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer fh.Close()
+		out = fh
+	}
+	w, err := transfer.NewWriter(out, f)
+	if err != nil {
+		return err
+	}
+	n, err := transfer.Export(ctx, repo, lf, w)
+	if err != nil {
+		return err
+	}
+	slog.Info("export done", "rows", n, "format", f)
+	if fh, ok := out.(*os.File); ok && fh != os.Stdout {
+		return fh.Close()
+	}
+	return nil
+}
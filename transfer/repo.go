@@ -0,0 +1,192 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/animans/REST-API-test-task/domain"
+)
+
+// Export writes every service matching f and returns how many, closing w if it
+// is an io.Closer. Limit, Cursor and WithTotal of f are ignored; without a sort
+// column the oldest start date comes first.
+func Export(ctx context.Context, repo domain.ServiceRepository, f domain.ListFilterService, w Writer) (int, error) {
+	if c, ok := w.(io.Closer); ok {
+		defer c.Close()
+	}
+	if f.SortBy == "" {
+		f.SortBy, f.SortDir = "service_created_at", "asc"
+	}
+	n := 0
+	for s, err := range repo.IterateByFilter(ctx, f) {
+		if err != nil {
+			return n, err
+		}
+		if err := w.Write(domain.NewServiceResponse(s)); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, w.Flush()
+}
+
+// ErrMalformed wraps read errors Import cannot attribute to a single line.
+var ErrMalformed = errors.New("malformed input")
+
+// BatchSize is the number of services Import saves per transaction.
+const BatchSize = 500
+
+// LineResult is the outcome of one input line.
+type LineResult struct {
+	Line   int                 `json:"line"`
+	ID     int                 `json:"id,omitempty"`
+	Error  string              `json:"error,omitempty"`
+	Fields []domain.FieldError `json:"errors,omitempty"`
+}
+
+// Report summarizes an import. Valid counts the lines that passed validation,
+// Imported the ones saved, which is zero on a dry run.
+type Report struct {
+	Total    int          `json:"total"`
+	Valid    int          `json:"valid"`
+	Imported int          `json:"imported"`
+	Failed   int          `json:"failed"`
+	DryRun   bool         `json:"dry_run"`
+	Lines    []LineResult `json:"lines"`
+}
+
+// fail records err for line.
+func (rep *Report) fail(line int, err error) {
+	rep.Failed++
+	rep.Lines = append(rep.Lines, failure(line, err))
+}
+
+// failure ...
+func failure(line int, err error) LineResult {
+	res := LineResult{Line: line, Error: err.Error()}
+	var verr *domain.ValidationError
+	if errors.As(err, &verr) {
+		res.Error, res.Fields = domain.ErrValidation.Error(), verr.Fields
+	}
+	return res
+}
+
+// ImportOptions ...
+type ImportOptions struct {
+	// DryRun validates every line without saving anything.
+	DryRun bool
+	// Check, when set, rejects a valid service, e.g. one of another user.
+	Check func(*domain.Service) error
+}
+
+// rejected reports whether the repository refused a service for a reason that
+// belongs in the report rather than failing the import.
+func rejected(err error) bool {
+	return errors.Is(err, domain.ErrValidation) || errors.Is(err, domain.ErrConflict) || errors.Is(err, domain.ErrForbidden)
+}
+
+// pending is a valid service waiting for its batch, at index slot of Report.Lines.
+type pending struct {
+	slot int
+	s    *domain.Service
+}
+
+// Import validates every record like a single create and saves the valid ones,
+// BatchSize per transaction when repo is a domain.BatchSaver and one by one
+// otherwise. Invalid lines and lines the repository rejects as invalid or
+// conflicting are reported and skipped; any other error stops the import.
+func Import(ctx context.Context, repo domain.ServiceRepository, r Reader, opts ImportOptions) (Report, error) {
+	rep := Report{DryRun: opts.DryRun, Lines: []LineResult{}}
+	batch := make([]pending, 0, BatchSize)
+	for {
+		rec, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return rep, rep.save(ctx, repo, batch)
+		}
+		if err != nil {
+			return rep, fmt.Errorf("%w: %v", ErrMalformed, err)
+		}
+		rep.Total++
+		s, err := rec.Request.ToService()
+		if rec.Err != nil {
+			// Report the other field errors of the line along with the decode error.
+			var verr, more *domain.ValidationError
+			if errors.As(rec.Err, &verr) && errors.As(err, &more) {
+				verr.Fields = append(verr.Fields, more.Fields...)
+			}
+			rep.fail(rec.Line, rec.Err)
+			continue
+		}
+		if err == nil && opts.Check != nil {
+			err = opts.Check(s)
+		}
+		if err != nil {
+			rep.fail(rec.Line, err)
+			continue
+		}
+		rep.Valid++
+		rep.Lines = append(rep.Lines, LineResult{Line: rec.Line})
+		if opts.DryRun {
+			continue
+		}
+		batch = append(batch, pending{slot: len(rep.Lines) - 1, s: s})
+		if len(batch) == BatchSize {
+			if err := rep.save(ctx, repo, batch); err != nil {
+				return rep, err
+			}
+			batch = batch[:0]
+		}
+	}
+}
+
+// save stores batch and fills in the ids of its lines. A service the
+// repository rejects is reported and the rest of the batch is retried.
+func (rep *Report) save(ctx context.Context, repo domain.ServiceRepository, batch []pending) error {
+	b, ok := repo.(domain.BatchSaver)
+	if !ok {
+		for _, p := range batch {
+			id, err := repo.Save(ctx, p.s)
+			if rejected(err) {
+				rep.reject(p, err)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			rep.Imported++
+			rep.Lines[p.slot].ID = id
+		}
+		return nil
+	}
+	for len(batch) > 0 {
+		ss := make([]*domain.Service, len(batch))
+		for i, p := range batch {
+			ss[i] = p.s
+		}
+		ids, err := b.SaveBatch(ctx, ss)
+		var berr *domain.BatchError
+		if errors.As(err, &berr) && rejected(berr.Err) && berr.Index < len(batch) {
+			rep.reject(batch[berr.Index], berr.Err)
+			batch = append(batch[:berr.Index:berr.Index], batch[berr.Index+1:]...)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		for i, p := range batch {
+			rep.Lines[p.slot].ID = ids[i]
+		}
+		rep.Imported += len(ids)
+		return nil
+	}
+	return nil
+}
+
+// reject turns the line of p into a failure.
+func (rep *Report) reject(p pending, err error) {
+	rep.Valid--
+	rep.Failed++
+	rep.Lines[p.slot] = failure(rep.Lines[p.slot].Line, err)
+}
@@ -0,0 +1,225 @@
+// Package transfer reads and writes services as JSON lines or CSV for import
+// and export, and writes XLSX spreadsheets.
+package transfer
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/animans/REST-API-test-task/domain"
+)
+
+// Formats.
+const (
+	FormatNDJSON = "ndjson"
+	FormatCSV    = "csv"
+	FormatXLSX   = "xlsx"
+)
+
+// ParseFormat accepts ndjson (or jsonl), csv and xlsx.
+func ParseFormat(s string) (string, error) {
+	switch strings.ToLower(s) {
+	case "ndjson", "jsonl":
+		return FormatNDJSON, nil
+	case "csv":
+		return FormatCSV, nil
+	case "xlsx":
+		return FormatXLSX, nil
+	}
+	return "", fmt.Errorf("unknown format %q: want ndjson, csv or xlsx", s)
+}
+
+// ContentType is the media type of an export in format.
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "application/x-ndjson"
+}
+
+// Columns is the CSV header of an export. Import needs service_name, price,
+// user_id and start_date in any order and ignores the rest.
+var Columns = []string{"id", "service_name", "price", "user_id", "start_date", "end_date", "created_at", "updated_at"}
+
+// required ...
+var required = []string{"service_name", "price", "user_id", "start_date"}
+
+// Writer encodes services one at a time.
+type Writer interface {
+	Write(s domain.ServiceResponse) error
+	Flush() error
+}
+
+// NewWriter writes format to w; CSV and XLSX start with the Columns header.
+// An XLSX workbook is only written to w by Flush.
+func NewWriter(w io.Writer, format string) (Writer, error) {
+	switch format {
+	case FormatNDJSON:
+		bw := bufio.NewWriter(w)
+		return &ndjsonWriter{w: bw, enc: json.NewEncoder(bw)}, nil
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(Columns); err != nil {
+			return nil, err
+		}
+		return &csvWriter{w: cw}, nil
+	case FormatXLSX:
+		return newXLSXWriter(w)
+	}
+	return nil, fmt.Errorf("unknown format %q", format)
+}
+
+// ndjsonWriter ...
+type ndjsonWriter struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+func (w *ndjsonWriter) Write(s domain.ServiceResponse) error { return w.enc.Encode(s) }
+func (w *ndjsonWriter) Flush() error                         { return w.w.Flush() }
+
+// csvWriter ...
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func (w *csvWriter) Write(s domain.ServiceResponse) error {
+	return w.w.Write([]string{
+		strconv.Itoa(s.ID), s.Name, strconv.Itoa(s.Price), s.Uuid, s.StartDate, s.EndDate,
+		s.CreatedAt.Format(time.RFC3339), s.UpdatedAt.Format(time.RFC3339),
+	})
+}
+
+func (w *csvWriter) Flush() error {
+	w.w.Flush()
+	return w.w.Error()
+}
+
+// Record is one input line. Err is set when the line could not be decoded;
+// the reader carries on with the next line.
+type Record struct {
+	Line    int
+	Request domain.CreatedRequest
+	Err     error
+}
+
+// Reader decodes records until io.EOF. Any other error ends the input.
+type Reader interface {
+	Read() (Record, error)
+}
+
+// NewReader reads format from r; CSV input must start with a header.
+func NewReader(r io.Reader, format string) (Reader, error) {
+	switch format {
+	case FormatNDJSON:
+		sc := bufio.NewScanner(r)
+		sc.Buffer(make([]byte, 64*1024), 1<<20)
+		return &ndjsonReader{sc: sc}, nil
+	case FormatCSV:
+		cr := csv.NewReader(r)
+		cr.FieldsPerRecord = -1
+		cr.ReuseRecord = true
+		header, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("csv: missing header")
+		}
+		if err != nil {
+			return nil, err
+		}
+		cols := make(map[string]int, len(header))
+		for i, h := range header {
+			cols[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))] = i
+		}
+		var missing []string
+		for _, c := range required {
+			if _, ok := cols[c]; !ok {
+				missing = append(missing, c)
+			}
+		}
+		if len(missing) > 0 {
+			return nil, fmt.Errorf("csv: header misses %s", strings.Join(missing, ", "))
+		}
+		return &csvReader{r: cr, cols: cols}, nil
+	}
+	return nil, fmt.Errorf("unknown format %q", format)
+}
+
+// ndjsonReader skips blank lines.
+type ndjsonReader struct {
+	sc   *bufio.Scanner
+	line int
+}
+
+func (r *ndjsonReader) Read() (Record, error) {
+	for r.sc.Scan() {
+		r.line++
+		b := r.sc.Bytes()
+		if len(strings.TrimSpace(string(b))) == 0 {
+			continue
+		}
+		rec := Record{Line: r.line}
+		if err := json.Unmarshal(b, &rec.Request); err != nil {
+			rec.Err = fmt.Errorf("invalid json: %w", err)
+		}
+		return rec, nil
+	}
+	if err := r.sc.Err(); err != nil {
+		return Record{}, err
+	}
+	return Record{}, io.EOF
+}
+
+// csvReader ...
+type csvReader struct {
+	r    *csv.Reader
+	cols map[string]int
+}
+
+func (r *csvReader) Read() (Record, error) {
+	row, err := r.r.Read()
+	if err != nil {
+		var perr *csv.ParseError
+		if errors.As(err, &perr) && !errors.Is(perr.Err, csv.ErrQuote) {
+			return Record{Line: perr.Line, Err: err}, nil
+		}
+		return Record{}, err
+	}
+	line, _ := r.r.FieldPos(0)
+	get := func(col string) string {
+		i, ok := r.cols[col]
+		if !ok || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+	if slices.IndexFunc(row, func(s string) bool { return strings.TrimSpace(s) != "" }) < 0 {
+		return r.Read()
+	}
+	rec := Record{Line: line, Request: domain.CreatedRequest{
+		Name:      get("service_name"),
+		Uuid:      get("user_id"),
+		StartDate: get("start_date"),
+		EndDate:   get("end_date"),
+	}}
+	if p := get("price"); p != "" {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			var verr domain.ValidationError
+			verr.Add("price", "must be an integer")
+			rec.Err = &verr
+		}
+		rec.Request.Price = n
+	}
+	return rec, nil
+}
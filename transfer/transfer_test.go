@@ -0,0 +1,205 @@
+package transfer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/animans/REST-API-test-task/domain"
+	"github.com/animans/REST-API-test-task/infastructure"
+	"github.com/google/uuid"
+	"github.com/xuri/excelize/v2"
+)
+
+func seeded(t *testing.T, n int) *infastructure.ServiceRepoMem {
+	t.Helper()
+	repo := infastructure.NewServiceRepoMem()
+	user := uuid.New()
+	for i := range n {
+		s := domain.NewService("Netflix", 100+i, user, time.Date(2024, time.Month(i%12+1), 1, 0, 0, 0, 0, time.UTC))
+		if i%2 == 0 {
+			end := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+			s.SetEndDate(&end)
+		}
+		if _, err := repo.Save(t.Context(), s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return repo
+}
+
+func TestRoundTrip(t *testing.T) {
+	const rows = 503
+	src := seeded(t, rows)
+	for _, format := range []string{FormatNDJSON, FormatCSV} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := NewWriter(&buf, format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			n, err := Export(t.Context(), src, domain.ListFilterService{}, w)
+			if err != nil || n != rows {
+				t.Fatalf("Export: %d, %v", n, err)
+			}
+
+			dst := infastructure.NewServiceRepoMem()
+			r, err := NewReader(&buf, format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rep, err := Import(t.Context(), dst, r, ImportOptions{})
+			if err != nil || rep.Imported != n || rep.Failed != 0 || len(rep.Lines) != n {
+				t.Fatalf("Import: %+v, %v", rep, err)
+			}
+			want, _ := src.SumByFilter(t.Context(), domain.SumFilterService{})
+			got, _ := dst.SumByFilter(t.Context(), domain.SumFilterService{})
+			if got.Total != want.Total || want.Total == 0 {
+				t.Fatalf("sum: got %+v want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestImportReport(t *testing.T) {
+	user := uuid.NewString()
+	in := strings.Join([]string{
+		`{"service_name":"Netflix","price":100,"user_id":"` + user + `","start_date":"01-2025"}`,
+		``,
+		`{"service_name":`,
+		`{"service_name":"","price":-1,"user_id":"` + user + `","start_date":"01-2025"}`,
+		`{"service_name":"Okko","price":100,"user_id":"` + user + `","start_date":"02-2025","end_date":"01-2025"}`,
+	}, "\n")
+	r, _ := NewReader(strings.NewReader(in), FormatNDJSON)
+	rep, err := Import(t.Context(), infastructure.NewServiceRepoMem(), r, ImportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.Total != 4 || rep.Imported != 1 || rep.Failed != 3 {
+		t.Fatalf("report: %+v", rep)
+	}
+	lines := []int{}
+	for _, l := range rep.Lines {
+		lines = append(lines, l.Line)
+	}
+	if len(lines) != 4 || lines[0] != 1 || lines[1] != 3 || lines[2] != 4 || lines[3] != 5 {
+		t.Fatalf("line numbers: %v", lines)
+	}
+	if l := rep.Lines[2]; l.Error != "validation failed" || len(l.Fields) != 2 {
+		t.Fatalf("field errors: %+v", l)
+	}
+}
+
+func TestCSVReader(t *testing.T) {
+	if _, err := NewReader(strings.NewReader("service_name,price\n"), FormatCSV); err == nil || !strings.Contains(err.Error(), "user_id, start_date") {
+		t.Fatalf("missing columns: %v", err)
+	}
+	if _, err := NewReader(strings.NewReader(""), FormatCSV); err == nil {
+		t.Fatal("empty input: expected error")
+	}
+	in := "\ufeffStart_Date,user_id,price,service_name,extra\n01-2025,u,abc,Netflix,x\n\n02-2025,u,5,\"Yandex, Plus\",y\n"
+	r, err := NewReader(strings.NewReader(in), FormatCSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec, _ := r.Read()
+	if rec.Line != 2 || rec.Err == nil || rec.Request.Name != "Netflix" {
+		t.Fatalf("first: %+v", rec)
+	}
+	rec, _ = r.Read()
+	if rec.Line != 4 || rec.Err != nil || rec.Request.Name != "Yandex, Plus" || rec.Request.Price != 5 {
+		t.Fatalf("second: %+v", rec)
+	}
+	if _, err := r.Read(); !errors.Is(err, io.EOF) {
+		t.Fatalf("end: %v", err)
+	}
+}
+
+// conflictRepo rejects services named Dup in SaveBatch like a unique constraint would.
+type conflictRepo struct {
+	*infastructure.ServiceRepoMem
+	calls int
+}
+
+func (r *conflictRepo) SaveBatch(ctx context.Context, ss []*domain.Service) ([]int, error) {
+	r.calls++
+	for i, s := range ss {
+		if s.GetName() == "Dup" {
+			return nil, &domain.BatchError{Index: i, Err: domain.ErrConflict}
+		}
+	}
+	return r.ServiceRepoMem.SaveBatch(ctx, ss)
+}
+
+func TestImportBatch(t *testing.T) {
+	user, other := uuid.NewString(), uuid.NewString()
+	line := func(name, user string) string {
+		return `{"service_name":"` + name + `","price":100,"user_id":"` + user + `","start_date":"01-2025"}`
+	}
+	in := strings.Join([]string{line("Netflix", user), line("Dup", user), line("Okko", other), line("Dup", user), line("Spotify", user)}, "\n")
+	check := func(s *domain.Service) error {
+		if s.GetUUID().String() != user {
+			return domain.ErrForbidden
+		}
+		return nil
+	}
+
+	repo := &conflictRepo{ServiceRepoMem: infastructure.NewServiceRepoMem()}
+	r, _ := NewReader(strings.NewReader(in), FormatNDJSON)
+	rep, err := Import(t.Context(), repo, r, ImportOptions{DryRun: true, Check: check})
+	if err != nil || rep.Valid != 4 || rep.Failed != 1 || rep.Imported != 0 || repo.calls != 0 {
+		t.Fatalf("dry run: %+v, %v, %d calls", rep, err, repo.calls)
+	}
+
+	r, _ = NewReader(strings.NewReader(in), FormatNDJSON)
+	rep, err = Import(t.Context(), repo, r, ImportOptions{Check: check})
+	if err != nil || rep.Valid != 2 || rep.Imported != 2 || rep.Failed != 3 || repo.calls != 3 {
+		t.Fatalf("import: %+v, %v, %d calls", rep, err, repo.calls)
+	}
+	for i, want := range []string{"", "service conflict", "forbidden", "service conflict", ""} {
+		if l := rep.Lines[i]; l.Line != i+1 || l.Error != want || (want == "") != (l.ID != 0) {
+			t.Fatalf("line %d: %+v", i+1, l)
+		}
+	}
+
+	r, _ = NewReader(strings.NewReader(line("Netflix", user)+"\n"+strings.Repeat("x", 2<<20)), FormatNDJSON)
+	if _, err := Import(t.Context(), repo, r, ImportOptions{}); !errors.Is(err, ErrMalformed) {
+		t.Fatalf("long line: %v", err)
+	}
+}
+
+func TestExportXLSX(t *testing.T) {
+	src := seeded(t, 3)
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, FormatXLSX)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := Export(t.Context(), src, domain.ListFilterService{SortBy: "service_price", SortDir: "desc"}, w)
+	if err != nil || n != 3 {
+		t.Fatalf("Export: %d, %v", n, err)
+	}
+
+	f, err := excelize.OpenReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	rows, err := f.GetRows(SheetName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 4 || strings.Join(rows[0], ",") != strings.Join(Columns, ",") {
+		t.Fatalf("rows: %v", rows)
+	}
+	if got := rows[1]; got[2] != "102" || got[4] != "03-2024" || got[5] != "01-2026" {
+		t.Fatalf("first row: %v", got)
+	}
+	if typ, _ := f.GetCellType(SheetName, "C2"); typ == excelize.CellTypeSharedString || typ == excelize.CellTypeInlineString {
+		t.Fatalf("price is not numeric: %v", typ)
+	}
+}
@@ -0,0 +1,81 @@
+package transfer
+
+import (
+	"io"
+	"time"
+
+	"github.com/animans/REST-API-test-task/domain"
+	"github.com/xuri/excelize/v2"
+)
+
+// SheetName is the worksheet of an XLSX export.
+const SheetName = "services"
+
+// xlsxWriter streams rows into a single sheet; excelize spills them to a
+// temporary file past its memory threshold.
+type xlsxWriter struct {
+	out  io.Writer
+	file *excelize.File
+	sw   *excelize.StreamWriter
+	row  int
+}
+
+// newXLSXWriter ...
+func newXLSXWriter(out io.Writer) (*xlsxWriter, error) {
+	f := excelize.NewFile()
+	if err := f.SetSheetName("Sheet1", SheetName); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	sw, err := f.NewStreamWriter(SheetName)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	w := &xlsxWriter{out: out, file: f, sw: sw}
+	header := make([]any, len(Columns))
+	for i, c := range Columns {
+		header[i] = c
+	}
+	if err := sw.SetPanes(&excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"}); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	if err := w.setRow(header); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// setRow ...
+func (w *xlsxWriter) setRow(values []any) error {
+	w.row++
+	cell, err := excelize.CoordinatesToCellName(1, w.row)
+	if err != nil {
+		return err
+	}
+	return w.sw.SetRow(cell, values)
+}
+
+// Write keeps ids and prices numeric and months as MM-YYYY text, like the API.
+func (w *xlsxWriter) Write(s domain.ServiceResponse) error {
+	return w.setRow([]any{
+		s.ID, s.Name, s.Price, s.Uuid, s.StartDate, s.EndDate,
+		s.CreatedAt.Format(time.RFC3339), s.UpdatedAt.Format(time.RFC3339),
+	})
+}
+
+// Flush writes the workbook to the underlying writer.
+func (w *xlsxWriter) Flush() error {
+	if err := w.sw.Flush(); err != nil {
+		return err
+	}
+	_, err := w.file.WriteTo(w.out)
+	return err
+}
+
+// Close removes the temporary files of the workbook.
+func (w *xlsxWriter) Close() error {
+	return w.file.Close()
+}